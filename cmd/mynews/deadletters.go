@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"mynews/internal/app/news"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/logger"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	deadLettersActionList   = "list"
+	deadLettersActionReplay = "replay"
+)
+
+var errUnknownDeadLettersAction = errors.New("unknown action, expected 'list' or 'replay'")
+
+// deadLetters inspects and replays stories which ran out of delivery attempts.
+// The daemon dumps its storage on exit, so it should be stopped while replaying.
+//
+//	mynews deadletters [-config path] [-storage path] [-app name] list
+//	mynews deadletters [-config path] [-storage path] [-app name] replay [id...]
+func deadLetters(args []string, log *logger.Log) {
+	var appName string
+
+	flags := flag.NewFlagSet(commandDeadLetters, flag.ExitOnError)
+	flags.StringVar(&appName, "app", "", "Limit to the app with the given broadcast name (e.g. 'telegram-<chatID>').")
+
+	cfg, err := config.New(log, flags, args)
+	if err != nil {
+		log.Fatal("initiating config failed", err)
+	}
+
+	if cfg == nil {
+		log.Warn("config is empty, exiting")
+		os.Exit(0)
+	}
+
+	action := deadLettersActionList
+	if flags.NArg() > 0 {
+		action = flags.Arg(0)
+	}
+
+	switch action {
+	case deadLettersActionList:
+		err = listDeadLetters(cfg, appName)
+		if err != nil {
+			log.Fatal("listing dead letters failed", err)
+		}
+	case deadLettersActionReplay:
+		replayDeadLetters(cfg, appName, flags.Args()[1:], log)
+	default:
+		log.Fatal(fmt.Sprintf("dead letters action '%s'", action), errUnknownDeadLettersAction)
+	}
+}
+
+func listDeadLetters(cfg *config.Config, appName string) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:mnd // table padding
+
+	_, err := fmt.Fprintln(writer, "APP\tID\tATTEMPTS\tENQUEUED\tTITLE\tURL\tLAST ERROR")
+	if err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+
+	for _, app := range cfg.Store.DeadLetterApps() {
+		if appName != "" && app != appName {
+			continue
+		}
+
+		for _, letter := range cfg.Store.DeadLetters(app) {
+			_, err = fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+				app, letter.ID, letter.Attempts, letter.EnqueuedAt.Format(time.RFC3339),
+				letter.Story.Title, letter.Story.URL, letter.LastError)
+			if err != nil {
+				return fmt.Errorf("writing dead letter: %w", err)
+			}
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("flushing table: %w", err)
+	}
+
+	return nil
+}
+
+func replayDeadLetters(cfg *config.Config, appName string, ids []string, log *logger.Log) {
+	for _, app := range cfg.Apps {
+		for _, broadcastClient := range app.Broadcasters() {
+			if appName != "" && broadcastClient.Name() != appName {
+				continue
+			}
+
+			delivered := news.ReplayDeadLetters(cfg, broadcastClient, ids, log)
+
+			log.Info(fmt.Sprintf("Replayed %d dead letters of '%s'", delivered, broadcastClient.Name()))
+		}
+	}
+
+	err := cfg.Store.DumpToFile(cfg.StorageFilePath)
+	if err != nil {
+		log.Fatal("failed to dump storage file", err)
+	}
+}
@@ -1,22 +1,63 @@
 package main
 
 import (
+	"flag"
 	"mynews/internal/app/news"
 	"mynews/internal/pkg/config"
 	"mynews/internal/pkg/logger"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 )
 
+const (
+	commandRun         = "run"
+	commandDeadLetters = "deadletters"
+)
+
 func main() {
 	log := logger.New(logger.Info)
 
-	cfg, err := config.New(log)
+	command, args := commandFromArgs(os.Args[1:])
+
+	switch command {
+	case commandDeadLetters:
+		deadLetters(args, log)
+	default:
+		run(args, log)
+	}
+}
+
+// commandFromArgs splits the optional subcommand from its arguments.
+// Bare flags without a subcommand are treated as the run command for backwards compatibility.
+func commandFromArgs(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commandRun, args
+	}
+
+	return args[0], args[1:]
+}
+
+func run(args []string, log *logger.Log) {
+	var debug, dryRun, once bool
+
+	flags := flag.NewFlagSet(commandRun, flag.ExitOnError)
+	flags.BoolVar(&debug, "debug", false, "Logs debug messages, e.g. the rules dropping stories.")
+	flags.BoolVar(&dryRun, "dry-run", false,
+		"Fetches all sources once and prints what would happen to each story, without broadcasting or saving anything.")
+	flags.BoolVar(&once, "once", false,
+		"Runs a single fetch and broadcast cycle, saves the storage and exits, with a nonzero code when sources failed.")
+
+	cfg, err := config.New(log, flags, args)
 	if err != nil {
 		log.Fatal("initiating config failed", err)
 	}
 
+	if debug {
+		log.SetLevel(logger.Debug)
+	}
+
 	if cfg == nil {
 		log.Warn("config is empty, exiting (if you have just created config, start the app again without create action)")
 		os.Exit(0)
@@ -27,12 +68,53 @@ func main() {
 		log.Fatal("initializing news runner failed", err)
 	}
 
+	if dryRun {
+		err = newsRunner.DryRun(os.Stdout, log)
+		if err != nil {
+			log.Fatal("dry run failed", err)
+		}
+
+		return
+	}
+
 	handleInterrupt(cfg, &newsRunner, log)
 
+	if once {
+		err = newsRunner.RunOnce(log)
+
+		shutdown(cfg, &newsRunner, log)
+
+		if err != nil {
+			log.Fatal("failed running feed", err)
+		}
+
+		return
+	}
+
+	handleReload(&newsRunner, log)
+
 	err = newsRunner.Run(log)
 	if err != nil {
 		log.Fatal("failed running feed", err)
 	}
+
+	// Run returns once shutting down, which exits the process after saving the storage
+	select {}
+}
+
+// handleReload reloads the config on SIGHUP, a config failing to load is reported and the current one kept.
+func handleReload(newsRunner *news.News, log *logger.Log) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	go func() {
+		for range c {
+			err := newsRunner.Reload(log)
+			if err != nil {
+				log.WarnErr("reloading config, keeping the current one", err)
+			}
+		}
+	}()
 }
 
 func handleInterrupt(cfg *config.Config, newsRunner *news.News, log *logger.Log) {
@@ -42,16 +124,21 @@ func handleInterrupt(cfg *config.Config, newsRunner *news.News, log *logger.Log)
 	go func() {
 		<-c
 
-		closeErr := newsRunner.Close()
-		if closeErr != nil {
-			log.WarnErr("failed to close news runner", closeErr)
-		}
-
-		dumpErr := cfg.Store.DumpToFile(cfg.StorageFilePath)
-		if dumpErr != nil {
-			log.Fatal("failed to dump storage file", dumpErr)
-		}
+		shutdown(cfg, newsRunner, log)
 
 		os.Exit(0)
 	}()
 }
+
+// shutdown closes the news runner and saves the storage.
+func shutdown(cfg *config.Config, newsRunner *news.News, log *logger.Log) {
+	closeErr := newsRunner.Close()
+	if closeErr != nil {
+		log.WarnErr("failed to close news runner", closeErr)
+	}
+
+	dumpErr := cfg.Store.DumpToFile(cfg.StorageFilePath)
+	if dumpErr != nil {
+		log.Fatal("failed to dump storage file", dumpErr)
+	}
+}
@@ -7,122 +7,390 @@ import (
 	"encoding/hex"
 	"fmt"
 	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/canonical"
 	"mynews/internal/pkg/config"
 	"mynews/internal/pkg/logger"
 	"mynews/internal/pkg/parser"
-	"strings"
+	"mynews/internal/pkg/scorer"
+	"mynews/internal/pkg/storage"
 	"time"
 )
 
+// scoringTimeout bounds scoring of a single story, batches get it for each of their stories.
 const scoringTimeout = 30 * time.Second
 
+// Decisions made about stories of a feed.
+const (
+	decisionSent           = "sent"
+	decisionDigest         = "digest"
+	decisionDuplicate      = "duplicate"
+	decisionFiltered       = "filtered"
+	decisionBelowThreshold = "below threshold"
+	decisionTooOld         = "too old"
+)
+
+// storyDecision tells what happened to a story of a feed and why.
+type storyDecision struct {
+	story    broadcast.Story
+	decision string // One of the decision constants
+	rule     string // Rule or reason behind the decision, or the route of digest stories, empty for sent stories
+	scored   bool
+}
+
+// broadcastFeed dispatches new stories of the feed, returning the decision made about each of its stories.
 func (n News) broadcastFeed(
-	briadcastClient broadcast.Broadcast,
+	app config.App,
 	stories []parser.Item,
 	source *config.Source,
 	log *logger.Log,
-) error {
-	for _, story := range stories {
-		if !storyMatchesConfig(story, source) {
-			continue
+) ([]storyDecision, error) {
+	briadcastClient := app.Broadcast
+
+	newStories, decisions, err := n.newStories(app, stories, source)
+	if err != nil {
+		return nil, err
+	}
+
+	storyScorer := n.scorerFor(app, source)
+
+	// new stories of the fetch are scored together, which is much faster than one by one
+	scored := n.scoreStories(storyScorer, newStories, log)
+
+	appClusters := n.loadClusters(app, newStories, storyScorer, log)
+
+	for _, newBroadcastMessage := range newStories {
+		queued := newQueuedStory(newBroadcastMessage.ID, newBroadcastMessage)
+
+		// low scored and filtered out stories are still registered, so they are not scored again on the next cycle
+		passes, rule := true, ""
+		if scored {
+			passes, rule = passesFilters(app, source, newBroadcastMessage, true)
 		}
 
-		storyID := buildStoryID(story.PublishedAt, story.Link, source.StatusPage)
+		switch {
+		case !passes:
+			decisions = append(decisions, storyDecision{
+				story:    newBroadcastMessage,
+				decision: decisionFiltered,
+				rule:     rule,
+				scored:   false,
+			})
+		case scored && newBroadcastMessage.Score < app.MinScore:
+			decisions = append(decisions, storyDecision{
+				story:    newBroadcastMessage,
+				decision: decisionBelowThreshold,
+				rule:     fmt.Sprintf("minScore %.2f", app.MinScore),
+				scored:   scored,
+			})
+		default:
+			queued, err = n.dispatchUnique(app, appClusters, queued, scored)
+			if err != nil {
+				return nil, err
+			}
+
+			decisions = append(decisions, n.dispatchDecision(app, queued, scored))
+		}
 
-		storyWasAlreadySent, err := n.cfg.Store.KeyExists(briadcastClient.Name(), storyID)
+		// all new stories are remembered, they make up the corpus of term based scorers
+		n.cfg.Store.RememberStory(briadcastClient.Name(), queued)
+
+		err = n.cfg.Store.PutKey(briadcastClient.Name(), newBroadcastMessage.ID)
 		if err != nil {
-			return fmt.Errorf("checking if story was already sent: %w", err)
+			return nil, fmt.Errorf("registering story as sent: %w", err)
+		}
+	}
+
+	for _, decision := range decisions {
+		if decision.decision != decisionSent {
+			log.Debug(fmt.Sprintf("story '%s' %s: %s", decision.story.URL, decision.decision, decision.rule))
+		}
+	}
+
+	return decisions, nil
+}
+
+// dispatchDecision tells whether the dispatched story was sent, went to the digest
+// or joined a near-duplicate kept before.
+func (n News) dispatchDecision(app config.App, queued storage.QueuedStory, scored bool) storyDecision {
+	if queued.Cluster == "" || queued.Cluster == queued.ID {
+		_, toDigest, route := destination(app, queued.Story.Score, scored)
+		if !toDigest {
+			return storyDecision{story: queued.Story, decision: decisionSent, rule: "", scored: scored}
 		}
 
-		if storyWasAlreadySent {
+		rule := "app digest"
+		if route != nil {
+			rule = routeRule(route)
+		}
+
+		return storyDecision{story: queued.Story, decision: decisionDigest, rule: rule, scored: scored}
+	}
+
+	rule := "near-duplicate"
+	if head, ok := n.cfg.Store.RecentStory(app.Broadcast.Name(), queued.Cluster); ok {
+		rule = fmt.Sprintf("near-duplicate of '%s'", head.Story.Title)
+	}
+
+	return storyDecision{story: queued.Story, decision: decisionDuplicate, rule: rule, scored: scored}
+}
+
+// newStories returns stories of the feed matching the source config which were not seen before,
+// along with decisions about the other ones.
+func (n News) newStories(
+	app config.App,
+	stories []parser.Item,
+	source *config.Source,
+) ([]broadcast.Story, []storyDecision, error) {
+	briadcastClient := app.Broadcast
+	mutedKeywords := n.cfg.Overlay.MutedKeywords(briadcastClient.Name())
+
+	var (
+		newStories []broadcast.Story
+		decisions  []storyDecision
+	)
+
+	seen := make(map[string]bool)
+
+	for _, story := range stories {
+		if matches, rule := storyMatchesConfig(story, app, source); !matches {
+			decision := decisionFiltered
+			if rule == ruleIgnoreStoriesBefore {
+				decision = decisionTooOld
+			}
+
+			decisions = append(decisions, storyDecision{
+				story:    feedStory("", story.Link, story, source),
+				decision: decision,
+				rule:     rule,
+				scored:   false,
+			})
+
+			continue
+		}
+
+		if keyword, muted := matchedKeyword(story.Title, mutedKeywords); muted {
+			decisions = append(decisions, storyDecision{
+				story:    feedStory("", story.Link, story, source),
+				decision: decisionFiltered,
+				rule:     fmt.Sprintf("muted keyword '%s'", keyword),
+				scored:   false,
+			})
+
 			continue
 		}
 
-		err = n.cfg.Store.PutKey(briadcastClient.Name(), storyID)
+		link := n.links.Resolve(context.Background(), story.Link)
+		storyID := buildStoryID(source.IDStrategy, story, canonical.Key(link))
+		newStory := feedStory(storyID, link, story, source)
+
+		// keys of earlier versions and of the link strategy keep stories from being sent again after
+		// an upgrade or a change of the strategy
+		storyWasAlreadySent, err := n.storyWasSent(briadcastClient.Name(), storyID,
+			legacyStoryID(source.IDStrategy, story), buildStoryID(config.IDStrategyLink, story, canonical.Key(link)))
 		if err != nil {
-			return fmt.Errorf("registering story as sent: %w", err)
+			return nil, nil, err
 		}
 
-		newBroadcastMessage := broadcast.Story{
-			Title:  story.Title,
-			URL:    story.Link,
-			Score:  0,
-			Reason: "",
+		if storyWasAlreadySent || seen[storyID] {
+			rule := "already sent"
+			if seen[storyID] {
+				rule = "repeated in the feed"
+			}
+
+			decisions = append(decisions, storyDecision{
+				story:    newStory,
+				decision: decisionDuplicate,
+				rule:     rule,
+				scored:   false,
+			})
+
+			continue
 		}
 
-		// Score the story if scoring is enabled
-		if n.scorer != nil {
-			ctx, cancel := context.WithTimeout(context.Background(), scoringTimeout)
-			score, scoreErr := n.scorer.Score(ctx, story.Title)
+		seen[storyID] = true
 
-			cancel()
+		if passes, rule := passesFilters(app, source, newStory, false); !passes {
+			decisions = append(decisions, storyDecision{
+				story:    newStory,
+				decision: decisionFiltered,
+				rule:     rule,
+				scored:   false,
+			})
 
-			if scoreErr != nil {
-				log.WarnErr("scoring story", scoreErr)
-			} else {
-				newBroadcastMessage.Score = score.Value
-				newBroadcastMessage.Reason = score.Reason
-			}
+			continue
 		}
 
-		err = briadcastClient.Send(newBroadcastMessage)
+		newStories = append(newStories, newStory)
+	}
+
+	return newStories, decisions, nil
+}
+
+// feedStory builds the story of the feed item, link is the resolved item link.
+func feedStory(id, link string, item parser.Item, source *config.Source) broadcast.Story {
+	return broadcast.Story{
+		ID:       id,
+		Title:    item.Title,
+		URL:      link,
+		Score:    0,
+		Scored:   false,
+		Reason:   "",
+		Interest: "",
+		Source:   source.Name,
+		Item:     item,
+
+		AlsoCoveredBy: nil,
+		Trending:      false,
+	}
+}
+
+// storyWasSent reports whether the story was registered before, under its ID or one of the previous IDs.
+// A previous key found is migrated to the ID. It is not looked up again afterwards, so it is soon cleaned up
+// and cannot match later versions of the story.
+func (n News) storyWasSent(appName, storyID string, previousIDs ...string) (bool, error) {
+	exists, err := n.cfg.Store.KeyExists(appName, storyID)
+	if err != nil {
+		return false, fmt.Errorf("checking if story was already sent: %w", err)
+	}
+
+	for _, previousID := range previousIDs {
+		if exists {
+			break
+		}
+
+		if previousID == storyID {
+			continue
+		}
+
+		exists, err = n.cfg.Store.KeyExists(appName, previousID)
 		if err != nil {
-			return fmt.Errorf("broadcasting story: %w", err)
+			return false, fmt.Errorf("checking if story was already sent: %w", err)
 		}
+	}
 
-		time.Sleep(n.cfg.SleepDurationBetweenBroadcasts)
+	if exists {
+		err = n.cfg.Store.PutKey(appName, storyID)
+		if err != nil {
+			return false, fmt.Errorf("migrating story key: %w", err)
+		}
 	}
 
-	return nil
+	return exists, nil
 }
 
-func storyMatchesConfig(story parser.Item, source *config.Source) bool {
-	if story.PublishedAtParsed.IsZero() {
+// scoreStories sets scores of the stories if scoring is enabled, reporting whether they were scored.
+func (n News) scoreStories(storyScorer scorer.Scorer, stories []broadcast.Story, log *logger.Log) bool {
+	if storyScorer == nil || len(stories) == 0 {
 		return false
 	}
 
-	if story.PublishedAtParsed.Before(source.IgnoreStoriesBefore) {
+	batch := make([]scorer.Story, len(stories))
+	for idx, story := range stories {
+		batch[idx] = scorer.Story{
+			Title:      story.Title,
+			Summary:    story.Item.Summary,
+			Content:    story.Item.Content,
+			Categories: story.Item.Categories,
+			Source:     story.Source,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(stories))*scoringTimeout)
+	defer cancel()
+
+	scores, err := storyScorer.ScoreBatch(ctx, batch)
+	if err != nil {
+		log.WarnErr("scoring stories", err)
+
 		return false
 	}
 
-	if len(source.MustExcludeKeywords) != 0 {
-		if includesKeywords(story.Title, source.MustExcludeKeywords) {
-			return false
-		}
+	for idx, score := range scores {
+		stories[idx].Score = score.Value
+		stories[idx].Scored = true
+		stories[idx].Reason = score.Reason
+		stories[idx].Interest = score.Interest
 	}
 
-	if len(source.MustIncludeKeywords) != 0 {
-		if !includesKeywords(story.Title, source.MustIncludeKeywords) {
-			return false
+	return true
+}
+
+// dispatch hands the story over to the digest or delivery queue,
+// picked by the score routes of the app for scored stories.
+func (n News) dispatch(app config.App, queued storage.QueuedStory, scored bool) error {
+	appName := app.Broadcast.Name()
+
+	target, toDigest, _ := destination(app, queued.Story.Score, scored)
+	if toDigest {
+		err := n.cfg.Store.AddToDigest(appName, queued)
+		if err != nil {
+			return fmt.Errorf("adding story to digest: %w", err)
 		}
+
+		return nil
 	}
 
-	return true
+	// the story stays pending until delivered, so a failed send is retried instead of lost
+	err := n.cfg.Store.Enqueue(target.Name(), queued)
+	if err != nil {
+		return fmt.Errorf("queueing story: %w", err)
+	}
+
+	return nil
 }
 
-func includesKeywords(target string, keywords []string) bool {
-	if len(keywords) == 0 {
-		return false
+// destination returns the broadcaster of the story or whether it goes to the app digest instead,
+// along with the route picking them, nil when the app defaults apply.
+func destination(app config.App, score float64, scored bool) (broadcast.Broadcast, bool, *config.Route) {
+	if route := app.Route(score); scored && route != nil {
+		return route.Broadcast, route.Digest, route
+	}
+
+	return app.Broadcast, app.Digest != nil, nil
+}
+
+// routeRule describes the score range of the route.
+func routeRule(route *config.Route) string {
+	if route.MaxScore == 0 {
+		return fmt.Sprintf("route score >= %g", route.MinScore)
 	}
 
-	target = strings.ToLower(target)
+	return fmt.Sprintf("route score %g-%g", route.MinScore, route.MaxScore)
+}
+
+// buildStoryID identifies the story according to the ID strategy of its source, link is the canonical link key.
+func buildStoryID(strategy string, story parser.Item, link string) string {
+	hash := md5.New() //nolint:gosec // speed is higher concern than security in this use case
 
-	for _, keyword := range keywords {
-		if strings.Contains(target, keyword) {
-			return true
+	switch strategy {
+	case config.IDStrategyGUID:
+		if story.GUID == "" {
+			_, _ = hash.Write([]byte(link))
+		} else {
+			_, _ = hash.Write([]byte("guid\x00" + story.GUID))
 		}
+	case config.IDStrategyLinkPublished:
+		_, _ = hash.Write([]byte(story.PublishedAt + link))
+	case config.IDStrategyTitleLink:
+		_, _ = hash.Write([]byte("title\x00" + story.Title + "\x00" + link))
+	case config.IDStrategyContent:
+		_, _ = hash.Write([]byte("content\x00" + story.Title + "\x00" + story.Summary + "\x00" + story.Content))
+	default:
+		_, _ = hash.Write([]byte(link))
 	}
 
-	return false
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
-func buildStoryID(published, link string, statusPage bool) string {
+// legacyStoryID is the ID stories got before links were canonicalized, status pages hashed
+// the publishing time along with the raw link.
+func legacyStoryID(strategy string, story parser.Item) string {
 	hash := md5.New() //nolint:gosec // speed is higher concern than security in this use case
 
-	if statusPage {
-		_, _ = hash.Write([]byte(published + link))
+	if strategy == config.IDStrategyLinkPublished {
+		_, _ = hash.Write([]byte(story.PublishedAt + story.Link))
 	} else {
-		_, _ = hash.Write([]byte(link))
+		_, _ = hash.Write([]byte(story.Link))
 	}
 
 	return hex.EncodeToString(hash.Sum(nil))
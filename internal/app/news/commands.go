@@ -0,0 +1,381 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/parser"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	commandsPollTimeout = 50 * time.Second
+	commandsRetryDelay  = 10 * time.Second
+)
+
+const commandsHelp = `Available commands:
+/sources - list sources
+/add <url> - add a source
+/remove <url or number> - remove a source
+/mute <keyword> - skip stories containing the keyword
+/unmute <keyword> - stop skipping stories containing the keyword
+/pause <duration, e.g. 2h> - pause the feed
+/resume - resume the feed
+/digest - send the digest now
+/stats - show feed statistics`
+
+var (
+	errMissingArgument = errors.New("missing argument, see /help")
+	errDigestDisabled  = errors.New("digest mode is not enabled for this feed")
+	errNoSuchSource    = errors.New("no such source, see /sources")
+	errUnknownChat     = errors.New("chat does not belong to any feed")
+	errStoryTooOld     = errors.New("story is too old to rate")
+)
+
+// pollers tracks bots being polled, so reloads start polling only bots which are not polled yet.
+type pollers struct {
+	mux    *sync.Mutex
+	tokens map[string]bool
+}
+
+// start reports whether polling of the bot should start, marking it as polled.
+func (p *pollers) start(token string) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.tokens[token] {
+		return false
+	}
+
+	p.tokens[token] = true
+
+	return true
+}
+
+func (p *pollers) stop(token string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	delete(p.tokens, token)
+}
+
+// listenCommands starts long-polling the bots of apps with commands or feedback buttons enabled,
+// unless they are polled already. Apps sharing a bot token share a single polling loop,
+// as Telegram allows only one per bot. Callers must hold cfgMux.
+func (n News) listenCommands(log *logger.Log) {
+	_, clients := n.commandBots()
+
+	for token, client := range clients {
+		if n.polling.start(token) {
+			go n.pollCommands(client, log)
+		}
+	}
+}
+
+// commandBots returns apps with commands or feedback buttons enabled and their Telegram clients, by bot token.
+func (n News) commandBots() (map[string][]config.App, map[string]*broadcast.Telegram) {
+	apps := make(map[string][]config.App)
+	clients := make(map[string]*broadcast.Telegram)
+
+	for _, app := range n.cfg.Apps {
+		for _, broadcastClient := range app.Broadcasters() {
+			telegramClient, ok := broadcastClient.(*broadcast.Telegram)
+			if !ok || (app.Commands == nil && !telegramClient.FeedbackButtons) {
+				continue
+			}
+
+			if !slices.ContainsFunc(apps[telegramClient.BotAPIToken], func(a config.App) bool {
+				return a.Broadcast.Name() == app.Broadcast.Name()
+			}) {
+				apps[telegramClient.BotAPIToken] = append(apps[telegramClient.BotAPIToken], app)
+			}
+
+			clients[telegramClient.BotAPIToken] = telegramClient
+		}
+	}
+
+	return apps, clients
+}
+
+// pollCommands handles updates of the bot until no app of a reloaded config uses it.
+func (n News) pollCommands(client *broadcast.Telegram, log *logger.Log) {
+	var offset int64
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), commandsPollTimeout+commandsRetryDelay)
+		updates, err := client.Updates(ctx, offset, commandsPollTimeout)
+
+		cancel()
+
+		if err != nil {
+			log.WarnErr("polling telegram bot commands", err)
+			time.Sleep(commandsRetryDelay)
+
+			continue
+		}
+
+		if !n.handleUpdates(client, updates, log) {
+			return
+		}
+
+		if len(updates) != 0 {
+			offset = updates[len(updates)-1].UpdateID + 1
+		}
+	}
+}
+
+// handleUpdates handles updates of the bot for apps of the current config, reporting whether any app uses the bot.
+// Polling of a bot no longer used stops, leaving the updates to the next poller of a bot added back.
+func (n News) handleUpdates(client *broadcast.Telegram, updates []broadcast.TelegramUpdate, log *logger.Log) bool {
+	n.running.RLock()
+	defer n.running.RUnlock()
+
+	current, apps := n.botSnapshot(client.BotAPIToken)
+	if len(apps) == 0 {
+		return false
+	}
+
+	for _, update := range updates {
+		if update.CallbackQuery != nil {
+			current.handleFeedback(client, apps, *update.CallbackQuery, log)
+
+			continue
+		}
+
+		if update.Message == nil || !strings.HasPrefix(update.Message.Text, "/") {
+			continue
+		}
+
+		current.handleCommand(client, apps, *update.Message, log)
+	}
+
+	return true
+}
+
+// botSnapshot returns a snapshot of the news and the apps using the bot, stopping polling of a bot no longer used.
+func (n News) botSnapshot(token string) (News, []config.App) {
+	n.cfgMux.RLock()
+	defer n.cfgMux.RUnlock()
+
+	botApps, _ := n.commandBots()
+
+	apps := botApps[token]
+	if len(apps) == 0 {
+		// stopped while holding cfgMux, so a reload adding the bot back starts a new poller
+		n.polling.stop(token)
+	}
+
+	return n.snapshotLocked(), apps
+}
+
+func (n News) handleCommand(
+	client *broadcast.Telegram,
+	apps []config.App,
+	message broadcast.TelegramIncomingMessage,
+	log *logger.Log,
+) {
+	// commands of other users are ignored without a reply, so the bot does not chat with every group member
+	app, ok := appForChat(apps, message)
+	if !ok {
+		if slices.ContainsFunc(apps, func(candidate config.App) bool { return isAdmin(candidate, message) }) {
+			n.reply(client, message, "Run commands in the chat of the feed.", log)
+		}
+
+		return
+	}
+
+	if !isAdmin(app, message) {
+		return
+	}
+
+	fields := strings.Fields(message.Text)
+	// commands in groups may be addressed to the bot as /command@bot_name
+	command, _, _ := strings.Cut(fields[0], "@")
+	argument := strings.TrimSpace(strings.TrimPrefix(message.Text, fields[0]))
+
+	log.Info(fmt.Sprintf("Running command '%s' of user %d for '%s'", command, message.From.ID, app.Broadcast.Name()))
+
+	response, err := n.runCommand(app, command, argument)
+	if err != nil {
+		response = "Failed: " + err.Error()
+	}
+
+	n.reply(client, message, response, log)
+}
+
+//nolint:cyclop // a flat switch over commands is the most readable
+func (n News) runCommand(app config.App, command, argument string) (string, error) {
+	appName := app.Broadcast.Name()
+
+	switch command {
+	case "/sources":
+		return n.listSources(app), nil
+	case "/add":
+		return n.addSource(app, argument)
+	case "/remove":
+		return n.removeSource(app, argument)
+	case "/mute", "/unmute":
+		if argument == "" {
+			return "", errMissingArgument
+		}
+
+		if command == "/mute" {
+			return "Muted '" + argument + "'.", n.cfg.Overlay.Mute(appName, argument)
+		}
+
+		return "Unmuted '" + argument + "'.", n.cfg.Overlay.Unmute(appName, argument)
+	case "/pause":
+		duration, err := time.ParseDuration(argument)
+		if err != nil {
+			return "", fmt.Errorf("parsing duration: %w", err)
+		}
+
+		until := time.Now().Add(duration)
+
+		return "Paused until " + until.Format(time.RFC1123) + ".", n.cfg.Overlay.Pause(appName, until)
+	case "/resume":
+		return "Resumed.", n.cfg.Overlay.Pause(appName, time.Time{})
+	case "/digest":
+		if app.Digest == nil {
+			return "", errDigestDisabled
+		}
+
+		included, err := n.sendDigest(app)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Digest with %d stories sent.", included), nil
+	case "/stats":
+		return n.stats(app), nil
+	default:
+		return commandsHelp, nil
+	}
+}
+
+func (n News) listSources(app config.App) string {
+	sources := n.cfg.Overlay.Sources(app.Broadcast.Name(), app.Sources)
+	if len(sources) == 0 {
+		return "No sources."
+	}
+
+	lines := make([]string, len(sources))
+	for idx, source := range sources {
+		lines[idx] = fmt.Sprintf("%d. %s - %s", idx+1, source.Name, source.URL)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (n News) addSource(app config.App, sourceURL string) (string, error) {
+	if sourceURL == "" {
+		return "", errMissingArgument
+	}
+
+	// make sure the source is a feed before persisting it
+	items, err := parser.ParseURL(sourceURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing feed: %w", err)
+	}
+
+	err = n.cfg.Overlay.AddSource(app.Broadcast.Name(), app.Sources, sourceURL)
+	if err != nil {
+		return "", fmt.Errorf("adding source: %w", err)
+	}
+
+	return fmt.Sprintf("Added %s (%d stories in the feed, only new ones will be sent).", sourceURL, len(items)), nil
+}
+
+func (n News) removeSource(app config.App, argument string) (string, error) {
+	if argument == "" {
+		return "", errMissingArgument
+	}
+
+	sourceURL := argument
+
+	if number, err := strconv.Atoi(argument); err == nil {
+		sources := n.cfg.Overlay.Sources(app.Broadcast.Name(), app.Sources)
+		if number < 1 || number > len(sources) {
+			return "", errNoSuchSource
+		}
+
+		sourceURL = sources[number-1].URL
+	}
+
+	err := n.cfg.Overlay.RemoveSource(app.Broadcast.Name(), app.Sources, sourceURL)
+	if err != nil {
+		return "", fmt.Errorf("removing source: %w", err)
+	}
+
+	return "Removed " + sourceURL + ".", nil
+}
+
+func (n News) stats(app config.App) string {
+	appName := app.Broadcast.Name()
+	stats := n.cfg.Store.Stats(appName)
+
+	lines := []string{
+		fmt.Sprintf("Sources: %d", len(n.cfg.Overlay.Sources(appName, app.Sources))),
+		fmt.Sprintf("Known stories: %d", stats.KnownStories),
+		fmt.Sprintf("Pending: %d", stats.Pending),
+		fmt.Sprintf("Dead letters: %d", stats.DeadLetters),
+	}
+
+	if app.Digest != nil {
+		lines = append(lines, fmt.Sprintf("Waiting for digest: %d", stats.DigestStories))
+	}
+
+	if muted := n.cfg.Overlay.MutedKeywords(appName); len(muted) != 0 {
+		lines = append(lines, "Muted: "+strings.Join(muted, ", "))
+	}
+
+	if pausedUntil := n.cfg.Overlay.PausedUntil(appName, time.Now()); !pausedUntil.IsZero() {
+		lines = append(lines, "Paused until "+pausedUntil.Format(time.RFC1123))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (n News) reply(client *broadcast.Telegram, to broadcast.TelegramIncomingMessage, text string, log *logger.Log) {
+	err := client.Reply(to, text)
+	if err != nil {
+		log.WarnErr("replying to telegram command", err)
+	}
+}
+
+// isAdmin reports whether the sender of the message may run commands of the app.
+func isAdmin(app config.App, message broadcast.TelegramIncomingMessage) bool {
+	return app.Commands != nil && slices.Contains(app.Commands.AdminIDs, message.From.ID)
+}
+
+// appForChat finds the app delivering to the chat of the message, including chats of its score routes.
+// Private chats with the bot resolve to the app only when the bot serves a single one.
+func appForChat(apps []config.App, message broadcast.TelegramIncomingMessage) (config.App, bool) {
+	for _, app := range apps {
+		for _, broadcastClient := range app.Broadcasters() {
+			telegramClient, ok := broadcastClient.(*broadcast.Telegram)
+			if !ok {
+				continue
+			}
+
+			for _, chat := range telegramClient.Chats {
+				if chat.ChatID == message.ChatID() {
+					return app, true
+				}
+			}
+		}
+	}
+
+	if message.Chat.Type == "private" && len(apps) == 1 {
+		return apps[0], true
+	}
+
+	return config.App{}, false
+}
@@ -0,0 +1,34 @@
+package news
+
+import (
+	"mynews/internal/pkg/scorer"
+)
+
+// index rebuilds the corpus of term based scorers from titles of recently seen stories of the apps they score.
+func (n News) index() {
+	documents := make(map[string][]string)
+
+	for _, app := range n.cfg.Apps {
+		var titles []string
+
+		for _, story := range n.cfg.Store.RecentStories(app.Broadcast.Name()) {
+			titles = append(titles, story.Story.Title)
+		}
+
+		keys := make(map[string]bool)
+
+		for _, scoring := range app.Scorings() {
+			keys[scorerKey(n.scorerConfig(scoring))] = true
+		}
+
+		for key := range keys {
+			documents[key] = append(documents[key], titles...)
+		}
+	}
+
+	for key, scorerInstance := range n.scorers {
+		if indexer, ok := scorerInstance.(scorer.Indexer); ok {
+			indexer.Index(documents[key])
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package news
+
+import (
+	"context"
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/dedupe"
+	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/scorer"
+	"mynews/internal/pkg/storage"
+	"time"
+)
+
+// clusters holds stories of an app kept out of their near-duplicates within the dedupe window.
+type clusters struct {
+	cfg        *config.DedupeConfig
+	heads      []clusterHead
+	embeddings map[string][]float64 // title -> unit embedding, nil when embeddings are not compared
+}
+
+// clusterHead is a story kept out of its near-duplicates.
+type clusterHead struct {
+	id        string
+	story     broadcast.Story
+	signature dedupe.Signature
+}
+
+// loadClusters collects stories kept within the dedupe window of the app, nil when the app does not dedupe.
+// Titles of the given new stories are embedded along with kept ones, when the scorer can embed texts.
+func (n News) loadClusters(
+	app config.App, stories []broadcast.Story, storyScorer scorer.Scorer, log *logger.Log,
+) *clusters {
+	if app.Dedupe == nil || len(stories) == 0 {
+		return nil
+	}
+
+	appClusters := &clusters{cfg: app.Dedupe, heads: nil, embeddings: nil}
+	since := time.Now().Add(-app.Dedupe.Window)
+
+	for _, story := range n.cfg.Store.RecentStories(app.Broadcast.Name()) {
+		if story.Cluster == story.ID && story.EnqueuedAt.After(since) {
+			appClusters.add(story.ID, story.Story)
+		}
+	}
+
+	embedder, ok := storyScorer.(scorer.Embedder)
+	if app.Dedupe.EmbeddingSimilarity == 0 || !ok {
+		return appClusters
+	}
+
+	titles := make([]string, 0, len(appClusters.heads)+len(stories))
+	for _, head := range appClusters.heads {
+		titles = append(titles, head.story.Title)
+	}
+
+	for _, story := range stories {
+		titles = append(titles, story.Title)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(stories))*scoringTimeout)
+	defer cancel()
+
+	embeddings, err := embedder.Embed(ctx, titles)
+	if err != nil {
+		log.WarnErr("embedding titles for near-duplicate detection", err)
+
+		return appClusters
+	}
+
+	appClusters.embeddings = make(map[string][]float64, len(titles))
+	for idx, title := range titles {
+		appClusters.embeddings[title] = embeddings[idx]
+	}
+
+	return appClusters
+}
+
+func (c *clusters) add(id string, story broadcast.Story) {
+	c.heads = append(c.heads, clusterHead{id: id, story: story, signature: dedupe.NewSignature(story.Title)})
+}
+
+func (c *clusters) replace(id string, story broadcast.Story) {
+	for idx := range c.heads {
+		if c.heads[idx].id == id {
+			c.heads[idx] = clusterHead{id: story.ID, story: story, signature: dedupe.NewSignature(story.Title)}
+		}
+	}
+}
+
+// match returns the kept story the story is a near-duplicate of, nil when it is not one.
+// Stories of the same source are never near-duplicates, follow-ups and corrections are worth sending.
+func (c *clusters) match(story broadcast.Story) *clusterHead {
+	var (
+		best           *clusterHead
+		bestSimilarity float64
+	)
+
+	signature := dedupe.NewSignature(story.Title)
+
+	for idx, head := range c.heads {
+		if head.story.Source == story.Source {
+			continue
+		}
+
+		similarity := signature.Similarity(head.signature)
+		matched := similarity >= c.cfg.TitleSimilarity
+
+		if embedding, ok := c.embeddings[story.Title]; ok {
+			embeddingSimilarity := dotProduct(embedding, c.embeddings[head.story.Title])
+			if embeddingSimilarity >= c.cfg.EmbeddingSimilarity {
+				matched, similarity = true, max(similarity, embeddingSimilarity)
+			}
+		}
+
+		if matched && similarity > bestSimilarity {
+			best, bestSimilarity = &c.heads[idx], similarity
+		}
+	}
+
+	return best
+}
+
+// dispatchUnique dispatches the story unless it is a near-duplicate of a kept one, which it then joins.
+// It returns the story with its cluster set, for remembering.
+func (n News) dispatchUnique(
+	app config.App, appClusters *clusters, queued storage.QueuedStory, scored bool,
+) (storage.QueuedStory, error) {
+	if appClusters == nil {
+		return queued, n.dispatch(app, queued, scored)
+	}
+
+	head := appClusters.match(queued.Story)
+	if head == nil {
+		queued.Cluster = queued.ID
+		appClusters.add(queued.ID, queued.Story)
+
+		return queued, n.dispatch(app, queued, scored)
+	}
+
+	appName := app.Broadcast.Name()
+
+	targets := make([]string, 0, len(app.Routes)+1)
+	for _, broadcaster := range app.Broadcasters() {
+		targets = append(targets, broadcaster.Name())
+	}
+
+	// the better story takes the place of the kept one, unless the kept one was already sent
+	if app.Dedupe.Keep == config.DedupeKeepBest && scored && queued.Story.Score > head.story.Score {
+		if kept, ok := n.cfg.Store.RemoveQueued(appName, targets, head.id); ok {
+			if app.Dedupe.AlsoCoveredBy {
+				for _, covered := range append(kept.Story.AlsoCoveredBy, coverageOf(kept.Story)) {
+					queued.Story.AlsoCoveredBy = addCoverage(queued.Story, covered)
+				}
+			}
+
+			if replaced, ok := n.cfg.Store.RecentStory(appName, head.id); ok {
+				replaced.Cluster = queued.ID
+				n.cfg.Store.RememberStory(appName, replaced)
+			}
+
+			queued.Cluster = queued.ID
+			appClusters.replace(head.id, queued.Story)
+
+			return queued, n.dispatch(app, queued, scored)
+		}
+	}
+
+	queued.Cluster = head.id
+
+	if app.Dedupe.AlsoCoveredBy {
+		n.cfg.Store.UpdateQueued(appName, targets, head.id, func(kept *storage.QueuedStory) {
+			kept.Story.AlsoCoveredBy = addCoverage(kept.Story, coverageOf(queued.Story))
+		})
+	}
+
+	return queued, nil
+}
+
+func coverageOf(story broadcast.Story) broadcast.Coverage {
+	return broadcast.Coverage{Source: story.Source, URL: story.URL}
+}
+
+// addCoverage lists the coverage with the story, once per source other than the one of the story.
+func addCoverage(story broadcast.Story, coverage broadcast.Coverage) []broadcast.Coverage {
+	if coverage.Source == story.Source {
+		return story.AlsoCoveredBy
+	}
+
+	for _, covered := range story.AlsoCoveredBy {
+		if covered.Source == coverage.Source {
+			return story.AlsoCoveredBy
+		}
+	}
+
+	return append(story.AlsoCoveredBy, coverage)
+}
+
+func dotProduct(vecA, vecB []float64) float64 {
+	if len(vecA) != len(vecB) {
+		return 0
+	}
+
+	var product float64
+	for idx := range vecA {
+		product += vecA[idx] * vecB[idx]
+	}
+
+	return product
+}
@@ -0,0 +1,177 @@
+package news
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/storage"
+	"slices"
+	"time"
+)
+
+const otherDigestGroup = "Other"
+
+// deliverDigest sends accumulated stories of the app once its digest schedule is due.
+// Stories are kept in storage when sending fails, so the digest is retried on the next cycle.
+func (n News) deliverDigest(app config.App, log *logger.Log) {
+	appName := app.Broadcast.Name()
+	now := time.Now()
+
+	lastDigestAt := n.cfg.Store.LastDigestAt(appName)
+	if lastDigestAt.IsZero() {
+		// count the schedule from the first run, instead of sending a digest right away
+		n.cfg.Store.SetLastDigestAt(appName, now)
+
+		return
+	}
+
+	dueAt, err := app.Digest.Schedule.Next(lastDigestAt)
+	if err != nil {
+		log.WarnErr(fmt.Sprintf("computing next digest time of '%s'", appName), err)
+
+		return
+	}
+
+	if now.Before(dueAt) {
+		return
+	}
+
+	_, err = n.sendDigest(app)
+	if err != nil {
+		log.WarnErr(fmt.Sprintf("sending digest of '%s'", appName), err)
+	}
+}
+
+// sendDigest sends accumulated stories of the app right away and returns how many were included.
+func (n News) sendDigest(app config.App) (int, error) {
+	n.digestMux.Lock()
+	defer n.digestMux.Unlock()
+
+	appName := app.Broadcast.Name()
+	now := time.Now()
+
+	stories := n.cfg.Store.DigestStories(appName)
+	if len(stories) == 0 {
+		n.cfg.Store.CompleteDigest(appName, nil, now)
+
+		return 0, nil
+	}
+
+	// stories not fitting into the digest message count as not included
+	top := buildDigest(app.Digest, stories)
+	digest := broadcast.FitDigest(app.Broadcast, top)
+
+	err := broadcast.SendDigest(app.Broadcast, digest)
+	if err != nil {
+		n.recordDigestDelivery(appName, stories, digest, err)
+
+		return 0, fmt.Errorf("sending digest: %w", err)
+	}
+
+	// stories which did not make it into the top are dropped together with the sent ones,
+	// top stories which did not fit into the message wait for the next digest
+	inTop, sent := digestStoryIDs(top), digestStoryIDs(digest)
+
+	completed := slices.DeleteFunc(slices.Clone(stories), func(queued storage.QueuedStory) bool {
+		return inTop[queued.ID] && !sent[queued.ID]
+	})
+
+	n.cfg.Store.CompleteDigest(appName, completed, now)
+
+	var included int
+	for _, group := range digest.Groups {
+		included += len(group.Stories)
+	}
+
+	return included, nil
+}
+
+// recordDigestDelivery marks stories of the digest which failed to send as delivered to chats which got it,
+// so the digest retried later skips them there.
+func (n News) recordDigestDelivery(appName string, stories []storage.QueuedStory, digest broadcast.Digest, err error) {
+	var partialErr *broadcast.PartialDeliveryError
+	if !errors.As(err, &partialErr) {
+		return
+	}
+
+	included := digestStoryIDs(digest)
+
+	for _, queued := range stories {
+		if !included[queued.ID] {
+			continue
+		}
+
+		for _, chatID := range partialErr.DeliveredTo {
+			if !slices.Contains(queued.Story.DeliveredTo, chatID) {
+				queued.Story.DeliveredTo = append(queued.Story.DeliveredTo, chatID)
+			}
+		}
+
+		// re-adding replaces the stored story
+		_ = n.cfg.Store.AddToDigest(appName, queued)
+	}
+}
+
+// digestStoryIDs returns IDs of the digest stories.
+func digestStoryIDs(digest broadcast.Digest) map[string]bool {
+	ids := make(map[string]bool)
+
+	for _, group := range digest.Groups {
+		for _, story := range group.Stories {
+			ids[story.ID] = true
+		}
+	}
+
+	return ids
+}
+
+// buildDigest groups the top scored stories by the configured key.
+// Groups are ordered by their best story, stories within a group by score.
+func buildDigest(digestConfig *config.DigestConfig, queued []storage.QueuedStory) broadcast.Digest {
+	stories := make([]broadcast.Story, len(queued))
+	for idx := range queued {
+		stories[idx] = queued[idx].Story
+	}
+
+	// stable sort keeps stories of equal score in the order they were found
+	slices.SortStableFunc(stories, func(a, b broadcast.Story) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	if digestConfig.TopN > 0 && len(stories) > digestConfig.TopN {
+		stories = stories[:digestConfig.TopN]
+	}
+
+	digest := broadcast.Digest{
+		Title:  digestConfig.Title,
+		Groups: nil,
+	}
+
+	groupIdx := make(map[string]int)
+
+	for _, story := range stories {
+		key := story.Source
+		if digestConfig.GroupBy == config.DigestGroupByReason {
+			key = story.Interest
+		}
+
+		if key == "" {
+			key = otherDigestGroup
+		}
+
+		idx, ok := groupIdx[key]
+		if !ok {
+			idx = len(digest.Groups)
+			groupIdx[key] = idx
+
+			digest.Groups = append(digest.Groups, broadcast.DigestGroup{Name: key, Stories: nil})
+		}
+
+		digest.Groups[idx].Stories = append(digest.Groups[idx].Stories, story)
+	}
+
+	return digest
+}
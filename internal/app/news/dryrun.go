@@ -0,0 +1,94 @@
+package news
+
+import (
+	"fmt"
+	"io"
+	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/parser"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// dryRunTitleLength is the number of title characters shown by the dry run table.
+const dryRunTitleLength = 60
+
+// DryRun fetches sources of all apps once and writes a table explaining the decision made about
+// each story, without broadcasting it. Decisions are made against a copy of the storage, which is left as is.
+func (n News) DryRun(out io.Writer, log *logger.Log) error {
+	cfg := *n.cfg
+	cfg.Store = n.cfg.Store.Clone()
+	n.cfg = &cfg
+
+	n.index()
+
+	for _, app := range n.cfg.Apps {
+		var decisions []storyDecision
+
+		for _, source := range n.cfg.Overlay.Sources(app.Broadcast.Name(), app.Sources) {
+			items, err := parser.ParseURL(source.URL)
+			if err != nil {
+				log.WarnErr(fmt.Sprintf("parsing feed of source '%s'", source.URL), err)
+
+				continue
+			}
+
+			sourceDecisions, err := n.broadcastFeed(app, items, source, log)
+			if err != nil {
+				log.WarnErr(fmt.Sprintf("evaluating items for source '%s'", source.URL), err)
+			}
+
+			decisions = append(decisions, sourceDecisions...)
+		}
+
+		err := writeDecisions(out, app.Broadcast.Name(), decisions)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeDecisions(out io.Writer, appName string, decisions []storyDecision) error {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+
+	_, _ = fmt.Fprintf(table, "\n%s (%d stories)\n", appName, len(decisions))
+	_, _ = fmt.Fprintln(table, "DECISION\tSCORE\tSOURCE\tTITLE\tDETAILS")
+
+	for _, decision := range decisions {
+		score := "-"
+		if decision.scored {
+			score = fmt.Sprintf("%.2f", decision.story.Score)
+		}
+
+		var details []string
+
+		for _, detail := range []string{decision.rule, decision.story.Reason} {
+			if detail != "" {
+				details = append(details, detail)
+			}
+		}
+
+		_, _ = fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", decision.decision, score, decision.story.Source,
+			shortTitle(decision.story.Title), strings.Join(details, "; "))
+	}
+
+	err := table.Flush()
+	if err != nil {
+		return fmt.Errorf("writing dry run decisions: %w", err)
+	}
+
+	return nil
+}
+
+// shortTitle fits the title into a table column, tabs and line breaks would break the table.
+func shortTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+
+	if utf8.RuneCountInString(title) <= dryRunTitleLength {
+		return title
+	}
+
+	return string([]rune(title)[:dryRunTitleLength-1]) + "…"
+}
@@ -0,0 +1,163 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/scorer"
+	"mynews/internal/pkg/storage"
+	"time"
+)
+
+const (
+	// storyHistoryRetention is how long story details are kept for rating through feedback buttons.
+	storyHistoryRetention = 7 * 24 * time.Hour
+
+	// feedbackRetention is how long ratings are kept and learned from, tastes drift.
+	feedbackRetention = 90 * 24 * time.Hour
+
+	// learningDelay lets ratings made in a row be learned from at once.
+	learningDelay = 30 * time.Second
+
+	learningTimeout = 5 * time.Minute
+)
+
+// handleFeedback records a rating made with the feedback buttons of a story and schedules retraining the scorer.
+func (n News) handleFeedback(
+	client *broadcast.Telegram,
+	apps []config.App,
+	query broadcast.TelegramCallbackQuery,
+	log *logger.Log,
+) {
+	storyID, positive, ok := broadcast.ParseFeedbackCallbackData(query.Data)
+	if !ok || query.Message == nil {
+		return
+	}
+
+	answer := "Thanks for the feedback!"
+
+	err := n.recordFeedback(apps, *query.Message, query.From.ID, storyID, positive)
+	if err != nil {
+		log.WarnErr("recording story feedback", err)
+
+		answer = "Could not record the feedback: " + err.Error()
+	}
+
+	err = client.AnswerCallback(query, answer)
+	if err != nil {
+		log.WarnErr("answering telegram callback", err)
+	}
+}
+
+func (n News) recordFeedback(
+	apps []config.App,
+	message broadcast.TelegramIncomingMessage,
+	userID int64,
+	storyID string,
+	positive bool,
+) error {
+	app, ok := appForChat(apps, message)
+	if !ok {
+		return errUnknownChat
+	}
+
+	appName := app.Broadcast.Name()
+
+	story, ok := n.cfg.Store.RecentStory(appName, storyID)
+	if !ok {
+		return errStoryTooOld
+	}
+
+	err := n.cfg.Store.PutFeedback(appName, storage.Feedback{
+		StoryID:  storyID,
+		UserID:   userID,
+		Positive: positive,
+		Title:    story.Story.Title,
+		Reason:   story.Story.Reason,
+		Interest: story.Story.Interest,
+		At:       time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("storing feedback: %w", err)
+	}
+
+	// a full pass over the feedback is costly, ratings are learned from in the background
+	select {
+	case n.learning <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// learnFromFeedback retrains scorers whenever ratings are recorded, waiting a moment to learn from
+// ratings made in a row at once. Learning works on a snapshot, leaving reloads and bot updates unblocked.
+func (n News) learnFromFeedback(log *logger.Log) {
+	for range n.learning {
+		n.pause(learningDelay)
+
+		if n.stopped() {
+			return
+		}
+
+		// ratings made while waiting are covered by this pass
+		select {
+		case <-n.learning:
+		default:
+		}
+
+		n.running.RLock()
+		n.snapshot().learn(log)
+		n.running.RUnlock()
+	}
+}
+
+// learn adjusts scorers to all feedback recorded so far, each learning from ratings of the apps it scores.
+func (n News) learn(log *logger.Log) {
+	examples := make(map[string][]scorer.Example)
+
+	for _, app := range n.cfg.Apps {
+		var appExamples []scorer.Example
+
+		for _, feedback := range n.cfg.Store.Feedback(app.Broadcast.Name()) {
+			interest := feedback.Interest
+			if interest == "" {
+				// ratings recorded before scores were explained hold the interest as the reason
+				interest = feedback.Reason
+			}
+
+			appExamples = append(appExamples, scorer.Example{
+				Text:     feedback.Title,
+				Interest: interest,
+				Positive: feedback.Positive,
+			})
+		}
+
+		keys := make(map[string]bool)
+
+		for _, scoring := range app.Scorings() {
+			keys[scorerKey(n.scorerConfig(scoring))] = true
+		}
+
+		for key := range keys {
+			examples[key] = append(examples[key], appExamples...)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), learningTimeout)
+	defer cancel()
+
+	for key, scorerExamples := range examples {
+		learner, ok := n.scorers[key].(scorer.Learner)
+		if !ok || len(scorerExamples) == 0 {
+			continue
+		}
+
+		err := learner.Learn(ctx, scorerExamples)
+		if err != nil {
+			log.WarnErr("learning from feedback", err)
+		}
+	}
+}
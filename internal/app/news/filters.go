@@ -0,0 +1,118 @@
+package news
+
+import (
+	"fmt"
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/filter"
+	"mynews/internal/pkg/parser"
+	"strings"
+)
+
+// ruleIgnoreStoriesBefore names the rule dropping stories published before the ignoreStoriesBefore of their source.
+const ruleIgnoreStoriesBefore = "ignoreStoriesBefore"
+
+// filterRules is a group of rules applying to stories, named after where it is configured.
+type filterRules struct {
+	name    string
+	include []string // Stories must include one of them
+	exclude []string // Stories must include none of them
+	expr    *filter.Expr
+}
+
+// rulesOf returns rules applying to stories of the source: rules of the app and its filter sets,
+// followed by rules of the source and its filter sets. Stories must pass all of them.
+func rulesOf(app config.App, source *config.Source) []filterRules {
+	rules := []filterRules{{name: "app", include: nil, exclude: nil, expr: app.Filter}}
+	rules = appendFilterSets(rules, app.FilterSets)
+
+	rules = append(rules, filterRules{
+		name:    "source",
+		include: source.MustIncludeKeywords,
+		exclude: source.MustExcludeKeywords,
+		expr:    source.Filter,
+	})
+
+	return appendFilterSets(rules, source.FilterSets)
+}
+
+func appendFilterSets(rules []filterRules, sets []*config.FilterSet) []filterRules {
+	for _, set := range sets {
+		rules = append(rules, filterRules{
+			name:    fmt.Sprintf("filter set '%s'", set.Name),
+			include: set.MustIncludeKeywords,
+			exclude: set.MustExcludeKeywords,
+			expr:    set.Filter,
+		})
+	}
+
+	return rules
+}
+
+// storyMatchesConfig reports whether the story passes the keyword rules of the app and the source,
+// naming the rule which rejected it otherwise. Filter expressions are evaluated by passesFilters.
+func storyMatchesConfig(story parser.Item, app config.App, source *config.Source) (bool, string) {
+	if story.PublishedAtParsed.IsZero() {
+		return false, "missing publishing time"
+	}
+
+	if story.PublishedAtParsed.Before(source.IgnoreStoriesBefore) {
+		return false, ruleIgnoreStoriesBefore
+	}
+
+	for _, rules := range rulesOf(app, source) {
+		if keyword, ok := matchedKeyword(story.Title, rules.exclude); ok {
+			return false, fmt.Sprintf("%s mustExcludeAnyOf '%s'", rules.name, keyword)
+		}
+
+		if _, ok := matchedKeyword(story.Title, rules.include); len(rules.include) != 0 && !ok {
+			return false, rules.name + " mustIncludeAnyOf"
+		}
+	}
+
+	return true, ""
+}
+
+// passesFilters reports whether the story matches filter expressions of the app and the source, naming
+// the rejecting one otherwise. Before scoring all expressions are evaluated with score conditions unknown,
+// so only the rest of an expression rejects the story. Once the story was scored, expressions using
+// the score are evaluated again. Stories which could not be scored are not filtered by their score.
+func passesFilters(app config.App, source *config.Source, story broadcast.Story, scored bool) (bool, string) {
+	filterStory := filter.Story{
+		Title:       story.Title,
+		Summary:     story.Item.Summary,
+		Content:     story.Item.Content,
+		Link:        story.URL,
+		Author:      story.Item.Author,
+		Source:      story.Source,
+		Categories:  story.Item.Categories,
+		PublishedAt: story.Item.PublishedAtParsed,
+		Score:       story.Score,
+		Unscored:    !scored,
+	}
+
+	for _, rules := range rulesOf(app, source) {
+		if rules.expr == nil || (scored && !rules.expr.UsesScore()) {
+			continue
+		}
+
+		if !rules.expr.Match(filterStory) {
+			return false, fmt.Sprintf("%s filter '%s'", rules.name, rules.expr)
+		}
+	}
+
+	return true, ""
+}
+
+// matchedKeyword returns the first of the keywords found in the target, ignoring case.
+func matchedKeyword(target string, keywords []string) (string, bool) {
+	target = strings.ToLower(target)
+
+	for _, keyword := range keywords {
+		if strings.Contains(target, strings.ToLower(keyword)) {
+			return keyword, true
+		}
+	}
+
+	return "", false
+}
@@ -1,64 +1,249 @@
 package news
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"mynews/internal/pkg/canonical"
 	"mynews/internal/pkg/config"
 	"mynews/internal/pkg/logger"
 	"mynews/internal/pkg/scorer"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // News handles RSS feed parsing and broadcasting.
 type News struct {
-	cfg    *config.Config
-	scorer scorer.Scorer
+	cfg     *config.Config
+	scorers map[string]scorer.Scorer // scoring config key -> scorer, apps and sources with equal scoring share one
+
+	embeddingCache *scorer.EmbeddingCache
+
+	links *canonical.Resolver // resolves story links into the ones identifying stories
+
+	digestMux *sync.Mutex // digests are sent both on schedule and on demand through bot commands
+
+	// reloads swap the config and scorers in place holding cfgMux, cycles and bot updates work on a snapshot of them
+	cfgMux    *sync.RWMutex
+	reloadMux *sync.Mutex  // reloads run one at a time, taking cfgMux only to swap
+	pool      *scorer.Pool // loads every embedding model once, however many interest sets or reloads use it
+
+	// held for reading while a snapshot is in use, scorers are closed only once no snapshot uses them
+	running  *sync.RWMutex
+	stop     chan struct{} // closed by Close, so a running cycle returns early
+	stopOnce *sync.Once
+
+	polling  *pollers
+	learning chan struct{} // signals recorded ratings to learn from
 }
 
 // New creates a new News instance with optional scoring.
 func New(cfg *config.Config, log *logger.Log) (News, error) {
 	newsInstance := News{
-		cfg:    cfg,
-		scorer: nil,
+		cfg:            cfg,
+		scorers:        make(map[string]scorer.Scorer),
+		embeddingCache: nil,
+		links:          canonical.NewResolver(cfg.Links.FollowRedirects, cfg.Links.DiscoverCanonical, cfg.Links.Timeout),
+		digestMux:      &sync.Mutex{},
+		cfgMux:         &sync.RWMutex{},
+		reloadMux:      &sync.Mutex{},
+		pool:           scorer.NewPool(),
+		running:        &sync.RWMutex{},
+		stop:           make(chan struct{}),
+		stopOnce:       &sync.Once{},
+		polling:        &pollers{mux: &sync.Mutex{}, tokens: make(map[string]bool)},
+		learning:       make(chan struct{}, 1),
 	}
 
-	if cfg.Scoring != nil && cfg.Scoring.Enabled {
-		modelDir := cfg.Scoring.ModelDir
-		if modelDir == "" {
-			modelDir = filepath.Join(filepath.Dir(cfg.StorageFilePath), "models")
-		}
-
-		log.Info(fmt.Sprintf("Initializing %s scorer with %d interests...", cfg.Scoring.Provider, len(cfg.Scoring.Interests)))
+	if cfg.EmbeddingCache != nil && slices.ContainsFunc(cfg.Apps, func(app config.App) bool { return len(app.Scorings()) != 0 }) {
+		var err error
 
-		embeddingScorer, keywordScorer, err := scorer.NewScorer(scorer.Config{
-			Provider:  cfg.Scoring.Provider,
-			Interests: cfg.Scoring.Interests,
-			ModelDir:  modelDir,
-			ModelName: cfg.Scoring.ModelName,
-		})
+		newsInstance.embeddingCache, err = scorer.NewEmbeddingCache(cfg.EmbeddingCache.FilePath, cfg.EmbeddingCache.MaxEntries)
 		if err != nil {
-			return News{}, fmt.Errorf("failed to initialize scorer: %w", err)
+			return News{}, fmt.Errorf("failed to load embedding cache: %w", err)
 		}
+	}
 
-		if embeddingScorer != nil {
-			newsInstance.scorer = embeddingScorer
-			log.Info(fmt.Sprintf("Scorer initialized successfully (provider: %s)", embeddingScorer.Name()))
-		} else if keywordScorer != nil {
-			newsInstance.scorer = keywordScorer
-			log.Info(fmt.Sprintf("Scorer initialized successfully (provider: %s)", keywordScorer.Name()))
+	for _, app := range cfg.Apps {
+		for _, scoring := range app.Scorings() {
+			err := newsInstance.initScorer(newsInstance.scorers, scoring, log)
+			if err != nil {
+				return News{}, err
+			}
 		}
 	}
 
+	newsInstance.learn(log)
+
 	return newsInstance, nil
 }
 
-// Close releases resources held by News.
+// initScorer adds the scorer of the scoring to the scorers, unless an equal one is there already.
+func (n News) initScorer(scorers map[string]scorer.Scorer, scoring *config.ScoringConfig, log *logger.Log) error {
+	scorerConfig := n.scorerConfig(scoring)
+	key := scorerKey(scorerConfig)
+
+	if _, ok := scorers[key]; ok {
+		return nil
+	}
+
+	log.Info(fmt.Sprintf("Initializing %s scorer with %d interests...", scoring.Provider, len(scoring.Interests)))
+
+	newScorer, err := n.pool.Scorer(scorerConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize scorer: %w", err)
+	}
+
+	scorers[key] = newScorer
+
+	log.Info(fmt.Sprintf("Scorer initialized successfully (provider: %s)", newScorer.Name()))
+
+	return nil
+}
+
+// scorerFor returns the scorer of the source, nil when its stories are not scored.
+func (n News) scorerFor(app config.App, source *config.Source) scorer.Scorer {
+	scoring := app.ScoringFor(source)
+	if scoring == nil {
+		return nil
+	}
+
+	return n.scorers[scorerKey(n.scorerConfig(scoring))]
+}
+
+func (n News) scorerConfig(scoring *config.ScoringConfig) scorer.Config {
+	modelDir := scoring.ModelDir
+	if modelDir == "" {
+		modelDir = filepath.Join(filepath.Dir(n.cfg.StorageFilePath), "models")
+	}
+
+	return scorer.Config{
+		Provider:      scoring.Provider,
+		Interests:     scoring.Interests,
+		AntiInterests: scoring.AntiInterests,
+		ModelDir:      modelDir,
+		ModelName:     scoring.ModelName,
+		FieldWeights:  scoring.FieldWeights,
+		MaxTokens:     scoring.MaxTokens,
+		Cache:         n.embeddingCache,
+		Workers:       scoring.Workers,
+		LLM:           scoring.LLM,
+		Composite:     scoring.Composite,
+	}
+}
+
+func scorerKey(cfg scorer.Config) string {
+	key := []string{cfg.Provider, cfg.ModelDir, cfg.ModelName, strconv.Itoa(cfg.MaxTokens), strconv.Itoa(cfg.Workers)}
+
+	if cfg.FieldWeights != nil {
+		key = append(key, fmt.Sprintf("%+v", *cfg.FieldWeights))
+	}
+
+	if cfg.LLM != nil {
+		key = append(key, fmt.Sprintf("%+v", *cfg.LLM))
+	}
+
+	if cfg.Composite != nil {
+		key = append(key, fmt.Sprintf("%+v", *cfg.Composite))
+	}
+
+	for _, interest := range cfg.Interests {
+		key = append(key, fmt.Sprintf("+%s:%g:%g", interest.Text, interest.Weight, interest.MinScore))
+	}
+
+	for _, interest := range cfg.AntiInterests {
+		key = append(key, fmt.Sprintf("-%s:%g:%g", interest.Text, interest.Weight, interest.MinScore))
+	}
+
+	return strings.Join(key, "\x00")
+}
+
+// snapshot returns the news with a copy of the current config and scorers, which reloads leave unchanged.
+// Callers must hold running for reading while using it.
+func (n News) snapshot() News {
+	n.cfgMux.RLock()
+	defer n.cfgMux.RUnlock()
+
+	return n.snapshotLocked()
+}
+
+// snapshotLocked is snapshot for callers holding cfgMux.
+func (n News) snapshotLocked() News {
+	cfg := *n.cfg
+
+	current := n
+	current.cfg = &cfg
+	current.scorers = maps.Clone(n.scorers)
+
+	return current
+}
+
+// stopped reports whether Close was called.
+func (n News) stopped() bool {
+	select {
+	case <-n.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// pause sleeps for the duration, waking up early when Close is called.
+func (n News) pause(duration time.Duration) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-n.stop:
+	}
+}
+
+// Close releases resources held by News. A running cycle is stopped early and waited for,
+// so scorers are not closed while in use.
 func (n News) Close() error {
-	if n.scorer != nil {
-		closeErr := n.scorer.Close()
+	n.stopOnce.Do(func() { close(n.stop) })
+
+	n.running.Lock()
+	defer n.running.Unlock()
+
+	n.cfgMux.Lock()
+	defer n.cfgMux.Unlock()
+
+	var errs []error
+
+	for _, scorerInstance := range n.scorers {
+		closeErr := scorerInstance.Close()
 		if closeErr != nil {
-			return fmt.Errorf("failed to close scorer: %w", closeErr)
+			errs = append(errs, closeErr)
 		}
 	}
 
+	cacheErr := n.saveEmbeddingCache()
+	if cacheErr != nil {
+		errs = append(errs, cacheErr)
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to close scorers: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func (n News) saveEmbeddingCache() error {
+	if n.embeddingCache == nil {
+		return nil
+	}
+
+	err := n.embeddingCache.Save()
+	if err != nil {
+		return fmt.Errorf("failed to save embedding cache: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,234 @@
+package news_test
+
+import (
+	//nolint:gosec // md5 is what story keys were built with
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mynews/internal/app/news"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/logger"
+)
+
+const (
+	testStoryLink      = "https://status.example.com/incidents/1"
+	testStoryPublished = "Mon, 19 Oct 2026 10:00:00 +0000"
+)
+
+const testFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Status</title>
+<item><title>Degraded API performance</title><link>` + testStoryLink + `</link>
+<pubDate>` + testStoryPublished + `</pubDate></item>
+</channel></rss>`
+
+// runOnce runs a single cycle of an app with the source over the test feed, its stories going to the digest.
+func runOnce(t *testing.T, app, source map[string]any, prepare func(cfg *config.Config)) *config.Config {
+	t.Helper()
+
+	runner, cfg := newRunner(t, app, source, prepare)
+
+	err := runner.RunOnce(logger.New(logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+// newRunner sets up an app with the source over the test feed, its stories going to the digest.
+func newRunner(t *testing.T, app, source map[string]any, prepare func(cfg *config.Config)) (news.News, *config.Config) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	if _, ok := source["url"]; !ok {
+		source["url"] = serveFeed(t, testFeed)
+	}
+	source["ignoreStoriesBefore"] = "2020-01-01T00:00:00Z"
+
+	app["broadcastType"] = "stdout"
+	app["digest"] = map[string]any{"enabled": true, "schedule": "0 8 * * *"}
+	app["sources"] = []any{source}
+
+	contents, err := json.Marshal(map[string]any{
+		"sleepDurationBetweenFeedParsing": "1m",
+		"sleepDurationBetweenBroadcasts":  "1ms",
+		"storageFilePath":                 filepath.Join(dir, "data.json"),
+		"embeddingCache":                  map[string]any{"enabled": false},
+		"apps":                            []any{app},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(dir, "config.json")
+
+	err = os.WriteFile(configPath, contents, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.New(logger.Error)
+
+	cfg, err := config.New(log, flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", configPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prepare != nil {
+		prepare(cfg)
+	}
+
+	runner, err := news.New(cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return runner, cfg
+}
+
+// serveFeed serves the feed, returning its URL.
+func serveFeed(t *testing.T, contents string) string {
+	t.Helper()
+
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(contents))
+	}))
+	t.Cleanup(feed.Close)
+
+	return feed.URL
+}
+
+func TestStatusPageKeyOfEarlierVersions(t *testing.T) {
+	t.Parallel()
+
+	cfg := runOnce(t, map[string]any{}, map[string]any{"statusPage": true}, func(cfg *config.Config) {
+		// status pages hashed the publishing time with the raw link before links were canonicalized
+		hash := md5.Sum([]byte(testStoryPublished + testStoryLink)) //nolint:gosec // see import
+
+		err := cfg.Store.PutKey("stdout", hex.EncodeToString(hash[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if stories := cfg.Store.DigestStories("stdout"); len(stories) != 0 {
+		t.Errorf("story sent before was sent again: %+v", stories)
+	}
+}
+
+// failingScoringApp is an app whose scorer fails on every story.
+func failingScoringApp(t *testing.T) map[string]any {
+	t.Helper()
+
+	model := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(model.Close)
+
+	return map[string]any{
+		"scoring": map[string]any{
+			"enabled":   true,
+			"provider":  "llm",
+			"interests": []string{"outages"},
+			"llm":       map[string]any{"endpoint": model.URL, "model": "test"},
+		},
+	}
+}
+
+func TestScoreFilterOfStoriesNotScored(t *testing.T) {
+	t.Parallel()
+
+	cfg := runOnce(t, failingScoringApp(t), map[string]any{"filter": "score > 0.5"}, nil)
+
+	if stories := cfg.Store.DigestStories("stdout"); len(stories) != 1 {
+		t.Errorf("got %d stories, story which failed to score should not be filtered by its score", len(stories))
+	}
+}
+
+func TestMixedFilterOfStoriesNotScored(t *testing.T) {
+	t.Parallel()
+
+	source := map[string]any{"filter": `title ~ "outage" && !(category in ["sponsored"]) && score > 0.5`}
+
+	cfg := runOnce(t, failingScoringApp(t), source, nil)
+
+	if stories := cfg.Store.DigestStories("stdout"); len(stories) != 0 {
+		t.Errorf("got %d stories, conditions besides the score should still filter stories which failed to score",
+			len(stories))
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	runner, cfg := newRunner(t, map[string]any{}, map[string]any{}, func(cfg *config.Config) {
+		err := cfg.Store.PutKey("stdout", "sent before")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	before := dumpStore(t, cfg)
+
+	var out strings.Builder
+
+	err := runner.DryRun(&out, logger.New(logger.Error))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "digest ") || !strings.Contains(out.String(), "app digest") {
+		t.Errorf("expected the story to go to the app digest, got:\n%s", out.String())
+	}
+
+	if after := dumpStore(t, cfg); after != before {
+		t.Errorf("dry run changed the storage from\n%s\nto\n%s", before, after)
+	}
+}
+
+func dumpStore(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "dump.json")
+
+	err := cfg.Store.DumpToFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(contents)
+}
+
+func TestNearDuplicatesOfSameSource(t *testing.T) {
+	t.Parallel()
+
+	feed := serveFeed(t, `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Status</title>
+<item><title>Degraded API performance in Europe</title><link>https://status.example.com/incidents/1</link>
+<pubDate>`+testStoryPublished+`</pubDate></item>
+<item><title>Degraded API performance in Europe resolved</title><link>https://status.example.com/incidents/2</link>
+<pubDate>`+testStoryPublished+`</pubDate></item>
+</channel></rss>`)
+
+	app := map[string]any{"dedupe": map[string]any{"enabled": true, "window": "24h", "titleSimilarity": 0.5}}
+
+	cfg := runOnce(t, app, map[string]any{"url": feed, "name": "status"}, nil)
+
+	if stories := cfg.Store.DigestStories("stdout"); len(stories) != 2 {
+		t.Errorf("got %d stories, a follow-up from the same source is not a near-duplicate", len(stories))
+	}
+}
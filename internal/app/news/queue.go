@@ -0,0 +1,144 @@
+package news
+
+import (
+	"errors"
+	"fmt"
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/storage"
+	"time"
+)
+
+// deliverPending sends due stories from the persistent queue of the broadcast client.
+// Failed stories are rescheduled with exponential backoff and moved to dead letters
+// once they run out of attempts.
+func (n News) deliverPending(broadcastClient broadcast.Broadcast, log *logger.Log) {
+	appName := broadcastClient.Name()
+
+	for _, queued := range n.cfg.Store.DuePending(appName, time.Now()) {
+		if n.stopped() {
+			return
+		}
+
+		err := broadcastClient.Send(queued.Story)
+		if err == nil {
+			n.cfg.Store.Dequeue(appName, queued.ID)
+
+			n.pause(n.cfg.SleepDurationBetweenBroadcasts)
+
+			continue
+		}
+
+		queued.Attempts++
+		queued.LastError = err.Error()
+
+		// chats which got the story are skipped by the retries
+		var partialErr *broadcast.PartialDeliveryError
+		if errors.As(err, &partialErr) {
+			queued.Story.DeliveredTo = append(queued.Story.DeliveredTo, partialErr.DeliveredTo...)
+		}
+
+		if queued.Attempts >= n.cfg.Retry.MaxAttempts {
+			log.WarnErr(fmt.Sprintf("story '%s' moved to dead letters after %d attempts", queued.Story.URL, queued.Attempts), err)
+
+			n.cfg.Store.MoveToDeadLetters(appName, queued)
+
+			continue
+		}
+
+		var retryAfterErr *broadcast.RetryAfterError
+
+		retryAfter := time.Duration(0)
+		if errors.As(err, &retryAfterErr) {
+			retryAfter = retryAfterErr.After
+		}
+
+		queued.NextAttemptAt = time.Now().Add(n.backoff(queued.Attempts, retryAfter))
+
+		log.WarnErr(fmt.Sprintf("broadcasting story '%s', will retry at %s",
+			queued.Story.URL, queued.NextAttemptAt.Format(time.RFC3339)), err)
+
+		enqueueErr := n.cfg.Store.Enqueue(appName, queued)
+		if enqueueErr != nil {
+			log.WarnErr("rescheduling story", enqueueErr)
+		}
+
+		if retryAfter > 0 {
+			// the target is rate limiting us, remaining stories would fail the same way
+			return
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt, honoring the delay requested by the target.
+func (n News) backoff(attempts int, retryAfter time.Duration) time.Duration {
+	delay := n.cfg.Retry.InitialBackoff
+
+	for range attempts - 1 {
+		delay *= 2
+
+		if delay >= n.cfg.Retry.MaxBackoff {
+			delay = n.cfg.Retry.MaxBackoff
+
+			break
+		}
+	}
+
+	return max(delay, retryAfter)
+}
+
+// ReplayDeadLetters sends dead letters of the broadcast client again.
+// When ids are given, only the matching dead letters are replayed.
+// Returns the number of successfully delivered stories.
+func ReplayDeadLetters(
+	cfg *config.Config,
+	broadcastClient broadcast.Broadcast,
+	ids []string,
+	log *logger.Log,
+) int {
+	appName := broadcastClient.Name()
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var delivered int
+
+	for _, letter := range cfg.Store.DeadLetters(appName) {
+		if len(wanted) != 0 && !wanted[letter.ID] {
+			continue
+		}
+
+		err := broadcastClient.Send(letter.Story)
+		if err != nil {
+			log.WarnErr(fmt.Sprintf("replaying dead letter '%s'", letter.ID), err)
+
+			continue
+		}
+
+		cfg.Store.RemoveDeadLetter(appName, letter.ID)
+
+		delivered++
+
+		time.Sleep(cfg.SleepDurationBetweenBroadcasts)
+	}
+
+	return delivered
+}
+
+func newQueuedStory(id string, story broadcast.Story) storage.QueuedStory {
+	now := time.Now()
+
+	return storage.QueuedStory{
+		ID:            id,
+		Story:         story,
+		Attempts:      0,
+		EnqueuedAt:    now,
+		NextAttemptAt: now,
+		LastError:     "",
+		Cluster:       "",
+		TrendingAt:    time.Time{},
+	}
+}
@@ -0,0 +1,203 @@
+package news
+
+import (
+	"fmt"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/scorer"
+	"os"
+	"strings"
+	"time"
+)
+
+// configWatchInterval is how often the config file is checked for changes, when reloading on change.
+const configWatchInterval = 5 * time.Second
+
+// Reload loads the config file again and swaps it in between feed cycles. A config failing to load or
+// its scorers failing to initialize leave the current one running. Storage, the overlay, the embedding cache
+// and link resolving are kept, as are scorers whose settings did not change. New scorers are initialized and
+// trained before the swap, so cycles and bot commands go on meanwhile. Scorers no longer used are closed
+// once the running cycle, which may still use them, finishes.
+func (n News) Reload(log *logger.Log) error {
+	n.reloadMux.Lock()
+	defer n.reloadMux.Unlock()
+
+	// only reloads change the config and scorers, the snapshot stays current until the swap
+	current := n.snapshot()
+
+	reloaded, err := current.cfg.Reload(log)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	scorers, created, err := current.reloadScorers(reloaded, log)
+	if err != nil {
+		return err
+	}
+
+	// kept scorers learned from the feedback already
+	learning := current
+	learning.cfg, learning.scorers = reloaded, created
+	learning.learn(log)
+
+	for _, setting := range restartSettings(current.cfg, reloaded) {
+		log.Warn(fmt.Sprintf("Config setting '%s' changed, it takes effect after a restart", setting))
+	}
+
+	changes := current.cfg.Changes(reloaded)
+
+	n.swap(reloaded, scorers, log)
+
+	if len(changes) == 0 {
+		changes = []string{"no apps, sources or broadcasters changed"}
+	}
+
+	log.Info("Config reloaded: " + strings.Join(changes, "; "))
+
+	return nil
+}
+
+// reloadScorers returns scorers of the reloaded config, reusing the current ones, along with the created ones.
+func (n News) reloadScorers(
+	reloaded *config.Config, log *logger.Log,
+) (map[string]scorer.Scorer, map[string]scorer.Scorer, error) {
+	scorers := make(map[string]scorer.Scorer)
+	created := make(map[string]scorer.Scorer)
+
+	for _, app := range reloaded.Apps {
+		for _, scoring := range app.Scorings() {
+			key := scorerKey(n.scorerConfig(scoring))
+			if current, ok := n.scorers[key]; ok {
+				scorers[key] = current
+
+				continue
+			}
+
+			err := n.initScorer(scorers, scoring, log)
+			if err != nil {
+				n.closeNewScorers(scorers, log)
+
+				return nil, nil, err
+			}
+
+			created[key] = scorers[key]
+		}
+	}
+
+	return scorers, created, nil
+}
+
+// swap puts the reloaded config and its scorers in place, closing scorers no longer used.
+func (n News) swap(reloaded *config.Config, scorers map[string]scorer.Scorer, log *logger.Log) {
+	n.cfgMux.Lock()
+	defer n.cfgMux.Unlock()
+
+	var retired []scorer.Scorer
+
+	for key, current := range n.scorers {
+		if _, ok := scorers[key]; ok {
+			continue
+		}
+
+		retired = append(retired, current)
+
+		delete(n.scorers, key)
+	}
+
+	if len(retired) != 0 {
+		go n.closeRetiredScorers(retired, log)
+	}
+
+	for key, newScorer := range scorers {
+		n.scorers[key] = newScorer
+	}
+
+	*n.cfg = *reloaded
+
+	n.listenCommands(log)
+}
+
+// restartSettings lists settings of the reloaded config which differ from the current ones but are
+// used by link resolving and the embedding cache, both set up once at start.
+func restartSettings(current, reloaded *config.Config) []string {
+	var settings []string
+
+	if current.Links != reloaded.Links {
+		settings = append(settings, "links")
+	}
+
+	currentCache, reloadedCache := current.EmbeddingCache, reloaded.EmbeddingCache
+	if (currentCache == nil) != (reloadedCache == nil) || (currentCache != nil && *currentCache != *reloadedCache) {
+		settings = append(settings, "embeddingCache")
+	}
+
+	return settings
+}
+
+// closeNewScorers closes scorers created for a reloaded config which failed to initialize.
+func (n News) closeNewScorers(scorers map[string]scorer.Scorer, log *logger.Log) {
+	for key, newScorer := range scorers {
+		if _, ok := n.scorers[key]; ok {
+			continue
+		}
+
+		closeErr := newScorer.Close()
+		if closeErr != nil {
+			log.WarnErr("closing scorer of the rejected config", closeErr)
+		}
+	}
+}
+
+// closeRetiredScorers closes scorers of the previous config after snapshots which may use them are released.
+func (n News) closeRetiredScorers(retired []scorer.Scorer, log *logger.Log) {
+	n.running.Lock()
+	defer n.running.Unlock()
+
+	for _, current := range retired {
+		closeErr := current.Close()
+		if closeErr != nil {
+			log.WarnErr("closing scorer of the previous config", closeErr)
+		}
+	}
+}
+
+// watchConfig reloads the config whenever its file is modified, as long as the config asks for it.
+func (n News) watchConfig(log *logger.Log) {
+	filePath, _ := n.watchedFile()
+	modifiedAt := fileModifiedAt(filePath)
+
+	for range time.Tick(configWatchInterval) {
+		filePath, enabled := n.watchedFile()
+
+		current := fileModifiedAt(filePath)
+		if !enabled || current.Equal(modifiedAt) {
+			modifiedAt = current
+
+			continue
+		}
+
+		modifiedAt = current
+
+		err := n.Reload(log)
+		if err != nil {
+			log.WarnErr("reloading changed config, keeping the current one", err)
+		}
+	}
+}
+
+// watchedFile returns the config file and whether it is reloaded on change.
+func (n News) watchedFile() (string, bool) {
+	n.cfgMux.RLock()
+	defer n.cfgMux.RUnlock()
+
+	return n.cfg.FilePath, n.cfg.ReloadOnChange
+}
+
+func fileModifiedAt(filePath string) time.Time {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
@@ -1,44 +1,124 @@
 package news
 
 import (
+	"errors"
 	"fmt"
 	"mynews/internal/pkg/logger"
 	"mynews/internal/pkg/parser"
 	"time"
 )
 
+var errSourcesFailed = errors.New("some sources failed")
+
+// Run fetches and broadcasts feeds in cycles until Close is called.
 func (n News) Run(log *logger.Log) error {
-	var sourceHadIssues bool
+	n.cfgMux.RLock()
+	n.listenCommands(log)
+	n.cfgMux.RUnlock()
+
+	go n.watchConfig(log)
+	go n.learnFromFeedback(log)
+
+	for !n.stopped() {
+		n.cycle(log)
+
+		n.cfgMux.RLock()
+		sleepDuration := n.cfg.SleepDurationBetweenFeedParsing
+		n.cfgMux.RUnlock()
+
+		n.pause(sleepDuration)
+	}
+
+	return nil
+}
+
+// RunOnce runs a single fetch and broadcast cycle, reporting an error when any of the sources failed.
+// Bot commands and feedback are not received, and stories failing to broadcast are retried by the next run.
+func (n News) RunOnce(log *logger.Log) error {
+	failedSources := n.cycle(log)
+	if failedSources != 0 {
+		return fmt.Errorf("%w: %d", errSourcesFailed, failedSources)
+	}
+
+	return nil
+}
+
+// cycle fetches sources of all apps and delivers their stories, returning the number of sources which failed.
+// The cycle works on a snapshot of the config, reloads take effect from the next one.
+func (n News) cycle(log *logger.Log) int {
+	n.running.RLock()
+	defer n.running.RUnlock()
+
+	return n.snapshot().cycleApps(log)
+}
+
+func (n News) cycleApps(log *logger.Log) int {
+	var failedSources int
 
-	for {
-		for _, app := range n.cfg.Apps {
-			parsingStartedAt := time.Now()
+	n.index()
 
-			for _, source := range app.Sources {
-				items, err := parser.ParseURL(source.URL)
-				if err != nil {
-					log.WarnErr(fmt.Sprintf("parsing feed of source '%s'", source.URL), err)
+	for _, app := range n.cfg.Apps {
+		if n.stopped() {
+			break
+		}
+
+		if !n.cfg.Overlay.PausedUntil(app.Broadcast.Name(), time.Now()).IsZero() {
+			continue
+		}
+
+		parsingStartedAt := time.Now()
+		sourceHadIssues := false
 
-					sourceHadIssues = true
+		for _, source := range n.cfg.Overlay.Sources(app.Broadcast.Name(), app.Sources) {
+			if n.stopped() {
+				return failedSources
+			}
 
-					continue
-				}
+			items, err := parser.ParseURL(source.URL)
+			if err != nil {
+				log.WarnErr(fmt.Sprintf("parsing feed of source '%s'", source.URL), err)
 
-				err = n.broadcastFeed(app.Broadcast, items, source, log)
-				if err != nil {
-					log.WarnErr(fmt.Sprintf("broadcasting items for source '%s'", source.URL), err)
+				sourceHadIssues = true
+				failedSources++
 
-					sourceHadIssues = true
-				}
+				continue
 			}
 
-			if !sourceHadIssues {
-				n.cfg.Store.CleanupBefore(app.Broadcast.Name(), parsingStartedAt)
+			_, err = n.broadcastFeed(app, items, source, log)
+			if err != nil {
+				log.WarnErr(fmt.Sprintf("broadcasting items for source '%s'", source.URL), err)
+
+				sourceHadIssues = true
+				failedSources++
 			}
+		}
+
+		err := n.announceTrending(app)
+		if err != nil {
+			log.WarnErr("announcing trending stories", err)
+		}
+
+		for _, broadcastClient := range app.Broadcasters() {
+			n.deliverPending(broadcastClient, log)
+		}
+
+		if app.Digest != nil {
+			n.deliverDigest(app, log)
+		}
 
-			sourceHadIssues = false
+		if !sourceHadIssues {
+			n.cfg.Store.CleanupBefore(app.Broadcast.Name(), parsingStartedAt)
 		}
 
-		time.Sleep(n.cfg.SleepDurationBetweenFeedParsing)
+		n.cfg.Store.ForgetStoriesBefore(app.Broadcast.Name(), time.Now().Add(-storyHistoryRetention))
+		n.cfg.Store.ForgetFeedbackBefore(app.Broadcast.Name(), time.Now().Add(-feedbackRetention))
+	}
+
+	// the cache is saved on exit too, saving every cycle keeps it after a crash
+	err := n.saveEmbeddingCache()
+	if err != nil {
+		log.WarnErr("saving embedding cache", err)
 	}
+
+	return failedSources
 }
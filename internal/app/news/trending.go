@@ -0,0 +1,80 @@
+package news
+
+import (
+	"fmt"
+	"maps"
+	"mynews/internal/pkg/config"
+	"mynews/internal/pkg/storage"
+	"slices"
+	"time"
+)
+
+// trendingIDPrefix keeps queue IDs of trending alerts apart from the kept stories they announce.
+const trendingIDPrefix = "trending:"
+
+// announceTrending queues an alert for each cluster of near-duplicates covered by enough distinct sources
+// within the trending window of the app. A cluster is announced once.
+func (n News) announceTrending(app config.App) error {
+	if app.Trending == nil {
+		return nil
+	}
+
+	appName := app.Broadcast.Name()
+	now := time.Now()
+	since := now.Add(-app.Trending.Window)
+
+	heads := make(map[string]storage.QueuedStory)
+	recent := make(map[string][]storage.QueuedStory) // cluster -> its stories seen within the window
+
+	for _, story := range n.cfg.Store.RecentStories(appName) {
+		if story.Cluster == "" {
+			continue
+		}
+
+		if story.Cluster == story.ID {
+			heads[story.ID] = story
+		}
+
+		if story.EnqueuedAt.After(since) {
+			recent[story.Cluster] = append(recent[story.Cluster], story)
+		}
+	}
+
+	target := app.Broadcast
+	if app.Trending.Broadcast != nil {
+		target = app.Trending.Broadcast
+	}
+
+	for _, cluster := range slices.Sorted(maps.Keys(recent)) {
+		head, ok := heads[cluster]
+		if !ok || !head.TrendingAt.IsZero() {
+			continue
+		}
+
+		alert := head.Story // remembered stories carry no feed item, the alert goes with the title and link
+		alert.DeliveredTo = nil
+		alert.ID = "" // alerts may go to chats of no app, so they are not rated
+		alert.Trending, alert.AlsoCoveredBy = true, nil
+
+		sources := make(map[string]bool)
+
+		for _, story := range recent[cluster] {
+			sources[story.Story.Source] = true
+			alert.AlsoCoveredBy = addCoverage(alert, coverageOf(story.Story))
+		}
+
+		if len(sources) < app.Trending.MinSources {
+			continue
+		}
+
+		err := n.cfg.Store.Enqueue(target.Name(), newQueuedStory(trendingIDPrefix+head.ID, alert))
+		if err != nil {
+			return fmt.Errorf("queueing trending alert: %w", err)
+		}
+
+		head.TrendingAt = now
+		n.cfg.Store.RememberStory(appName, head)
+	}
+
+	return nil
+}
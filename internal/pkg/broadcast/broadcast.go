@@ -1,18 +1,171 @@
 package broadcast
 
+import (
+	"fmt"
+	"mynews/internal/pkg/parser"
+	"slices"
+	"time"
+)
+
 type Config struct {
 	StdOut   Broadcast
 	Telegram Broadcast
 }
 
 type Story struct {
-	Title  string  `json:"title"`
-	URL    string  `json:"url"`
-	Score  float64 `json:"score,omitempty"`
-	Reason string  `json:"scoreReason,omitempty"`
+	ID       string      `json:"id,omitempty"` // Storage key of the story
+	Title    string      `json:"title"`
+	URL      string      `json:"url"`
+	Score    float64     `json:"score,omitempty"`
+	Scored   bool        `json:"scored,omitempty"`      // Whether Score was set by a scorer
+	Reason   string      `json:"scoreReason,omitempty"` // Explanation of the score
+	Interest string      `json:"interest,omitempty"`    // Scoring interest the story matched best
+	Source   string      `json:"source,omitempty"`
+	Item     parser.Item `json:"item"` // Feed item the story was built from, available to templates
+
+	AlsoCoveredBy []Coverage `json:"alsoCoveredBy,omitempty"` // Near-duplicates of the story from other sources
+	Trending      bool       `json:"trending,omitempty"`      // Alert about the story spreading across AlsoCoveredBy sources
+
+	DeliveredTo []string `json:"deliveredTo,omitempty"` // Chats which got the story already, skipped when it is sent again
+}
+
+// Coverage is a near-duplicate of a story which was not sent on its own.
+type Coverage struct {
+	Source string `json:"source"`
+	URL    string `json:"url"`
 }
 
 type Broadcast interface {
 	Send(message Story) error
 	Name() string
 }
+
+// Digest is a batch of stories delivered as a single summary.
+type Digest struct {
+	Title  string        `json:"title"`
+	Groups []DigestGroup `json:"groups"`
+}
+
+// DigestGroup holds digest stories sharing the same source or scoring interest.
+type DigestGroup struct {
+	Name    string  `json:"name"`
+	Stories []Story `json:"stories"`
+}
+
+// undeliveredTo returns the digest without stories delivered to the chat before, and without groups left empty.
+func (d Digest) undeliveredTo(chatID string) Digest {
+	undelivered := Digest{Title: d.Title, Groups: nil}
+
+	for _, group := range d.Groups {
+		var stories []Story
+
+		for _, story := range group.Stories {
+			if !slices.Contains(story.DeliveredTo, chatID) {
+				stories = append(stories, story)
+			}
+		}
+
+		if len(stories) != 0 {
+			undelivered.Groups = append(undelivered.Groups, DigestGroup{Name: group.Name, Stories: stories})
+		}
+	}
+
+	return undelivered
+}
+
+// first returns the digest with only its first count stories, and without groups left empty.
+func (d Digest) first(count int) Digest {
+	head := Digest{Title: d.Title, Groups: nil}
+
+	for _, group := range d.Groups {
+		if count <= 0 {
+			break
+		}
+
+		stories := group.Stories[:min(count, len(group.Stories))]
+		count -= len(stories)
+
+		head.Groups = append(head.Groups, DigestGroup{Name: group.Name, Stories: stories})
+	}
+
+	return head
+}
+
+// digestFitter is implemented by broadcasters whose digest message can hold only so many stories.
+type digestFitter interface {
+	fitDigest(digest Digest) Digest
+}
+
+// FitDigest returns the part of the digest the broadcaster is able to deliver, so callers know which stories
+// were included. Broadcasters without message limits deliver the whole digest.
+func FitDigest(broadcaster Broadcast, digest Digest) Digest {
+	if fitter, ok := broadcaster.(digestFitter); ok {
+		return fitter.fitDigest(digest)
+	}
+
+	return digest
+}
+
+// DigestBroadcast is implemented by broadcasters which can deliver a digest as a single message.
+type DigestBroadcast interface {
+	Broadcast
+	SendDigest(digest Digest) error
+}
+
+// SendDigest delivers the digest through the broadcaster, falling back to
+// sending stories one by one when the broadcaster is not digest-aware.
+func SendDigest(broadcaster Broadcast, digest Digest) error {
+	if digestBroadcaster, ok := broadcaster.(DigestBroadcast); ok {
+		return digestBroadcaster.SendDigest(digest)
+	}
+
+	for _, group := range digest.Groups {
+		for _, story := range group.Stories {
+			err := broadcaster.Send(story)
+			if err != nil {
+				return fmt.Errorf("sending digest story: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RetryAfterError is returned when the broadcast target asks to wait before sending again.
+type RetryAfterError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", e.Err, e.After)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// PartialDeliveryError is returned by broadcasters delivering to several chats when some of them got
+// the message before another one failed. Recording the chats in DeliveredTo of a story retried later
+// keeps them from getting it twice.
+type PartialDeliveryError struct {
+	DeliveredTo []string // Chats which got the message in the failed attempt
+	Err         error
+}
+
+func (e *PartialDeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialDeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// partialDelivery wraps the error into a PartialDeliveryError when some chats got the message.
+func partialDelivery(deliveredTo []string, err error) error {
+	if len(deliveredTo) == 0 {
+		return err
+	}
+
+	return &PartialDeliveryError{DeliveredTo: deliveredTo, Err: err}
+}
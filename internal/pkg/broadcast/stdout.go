@@ -6,19 +6,21 @@ import (
 	"os"
 )
 
-type StdOut struct{}
+type StdOut struct {
+	Template *Template // Optional message template, JSON is printed when not set
+}
 
 func NewStdOutClient() *StdOut {
-	return &StdOut{}
+	return &StdOut{Template: nil}
 }
 
 func (s StdOut) Send(message Story) error {
-	res, err := json.Marshal(message)
+	text, err := s.format(message)
 	if err != nil {
-		return fmt.Errorf("marshaling message to JSON failed: %w", err)
+		return err
 	}
 
-	_, err = fmt.Fprintln(os.Stdout, string(res))
+	_, err = fmt.Fprintln(os.Stdout, text)
 	if err != nil {
 		return fmt.Errorf("failed to write message to stdout: %w", err)
 	}
@@ -26,6 +28,82 @@ func (s StdOut) Send(message Story) error {
 	return nil
 }
 
+func (s StdOut) format(message Story) (string, error) {
+	if s.Template != nil {
+		text, err := s.Template.Render(message)
+		if err != nil {
+			return "", fmt.Errorf("rendering message template: %w", err)
+		}
+
+		return text, nil
+	}
+
+	res, err := json.Marshal(newStdOutStory(message))
+	if err != nil {
+		return "", fmt.Errorf("marshaling message to JSON failed: %w", err)
+	}
+
+	return string(res), nil
+}
+
+// stdOutStory holds the story fields printed as JSON, leaving out the raw feed item.
+type stdOutStory struct {
+	Title  string  `json:"title"`
+	URL    string  `json:"url"`
+	Score  float64 `json:"score,omitempty"`
+	Reason string  `json:"scoreReason,omitempty"`
+	Source string  `json:"source,omitempty"`
+
+	AlsoCoveredBy []Coverage `json:"alsoCoveredBy,omitempty"`
+	Trending      bool       `json:"trending,omitempty"`
+}
+
+func newStdOutStory(message Story) stdOutStory {
+	return stdOutStory{
+		Title:         message.Title,
+		URL:           message.URL,
+		Score:         message.Score,
+		Reason:        message.Reason,
+		Source:        message.Source,
+		AlsoCoveredBy: message.AlsoCoveredBy,
+		Trending:      message.Trending,
+	}
+}
+
+type stdOutDigestGroup struct {
+	Name    string        `json:"name"`
+	Stories []stdOutStory `json:"stories"`
+}
+
+func (s StdOut) SendDigest(digest Digest) error {
+	groups := make([]stdOutDigestGroup, len(digest.Groups))
+	for idx, group := range digest.Groups {
+		groups[idx] = stdOutDigestGroup{Name: group.Name, Stories: make([]stdOutStory, len(group.Stories))}
+
+		for storyIdx, story := range group.Stories {
+			groups[idx].Stories[storyIdx] = newStdOutStory(story)
+		}
+	}
+
+	res, err := json.Marshal(struct {
+		Title  string              `json:"title"`
+		Groups []stdOutDigestGroup `json:"groups"`
+	}{
+		Title:  digest.Title,
+		Groups: groups,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling digest to JSON failed: %w", err)
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, string(res))
+	if err != nil {
+		return fmt.Errorf("failed to write digest to stdout: %w", err)
+	}
+
+	return nil
+}
+
 func (s StdOut) Name() string {
 	return "stdout"
 }
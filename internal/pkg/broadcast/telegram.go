@@ -7,82 +7,308 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mynews/internal/pkg/parser"
 	"mynews/internal/pkg/validate"
 	"net/http"
-	"strings"
+	"slices"
+	"time"
 )
 
-type Telegram struct {
+// TelegramConfig configures a Telegram broadcaster.
+type TelegramConfig struct {
 	BotAPIToken string
-	ChatID      string
+	Chats       []TelegramChat // Stories are delivered to every chat, the first one identifies the app
+	Template    *Template      // Optional message template, must produce valid markup of the parse mode
+
+	ParseMode          string // TelegramParseModeMarkdownV2 (default) or TelegramParseModeHTML
+	DisableLinkPreview bool
+	SendPhotos         bool // Send stories with an image as photos with a caption
+	FetchOGImage       bool // Look up og:image of the story page when the feed item has no image
+
+	SilentBelowScore float64 // Scored stories below are delivered without a notification
+	ProtectContent   bool    // Prevent forwarding and saving of the messages
+
+	FeedbackButtons bool // Add 👍/👎 buttons to rate the story relevance
 }
 
-func NewTelegramClient(botAPIToken, chatID string) (*Telegram, error) {
-	client := Telegram{
-		BotAPIToken: botAPIToken,
-		ChatID:      chatID,
-	}
+// TelegramChat is a chat the stories are delivered to, optionally routed to forum topics.
+type TelegramChat struct {
+	ChatID            string
+	DefaultThreadID   int            // Forum topic used when no route matches, 0 for the general topic
+	ThreadsBySource   map[string]int // Source name to forum topic
+	ThreadsByInterest map[string]int // Scoring interest to forum topic, takes precedence over the source
+}
+
+type Telegram struct {
+	TelegramConfig
+}
+
+var errUnknownTelegramParseMode = errors.New("unknown telegram parse mode")
 
-	err := validate.RequiredString(client.BotAPIToken, "Telegram API Token")
+func NewTelegramClient(cfg TelegramConfig) (*Telegram, error) {
+	err := validate.RequiredString(cfg.BotAPIToken, "Telegram API Token")
 	if err != nil {
 		return nil, fmt.Errorf("validating Telegram API Token: %w", err)
 	}
 
-	err = validate.RequiredString(client.ChatID, "Telegram Chat ID")
-	if err != nil {
-		return nil, fmt.Errorf("validating Telegram Chat ID: %w", err)
+	if len(cfg.Chats) == 0 {
+		cfg.Chats = []TelegramChat{{ChatID: "", DefaultThreadID: 0, ThreadsBySource: nil, ThreadsByInterest: nil}}
+	}
+
+	for _, chat := range cfg.Chats {
+		err = validate.RequiredString(chat.ChatID, "Telegram Chat ID")
+		if err != nil {
+			return nil, fmt.Errorf("validating Telegram Chat ID: %w", err)
+		}
 	}
 
-	return &client, nil
+	switch cfg.ParseMode {
+	case "":
+		cfg.ParseMode = TelegramParseModeMarkdownV2
+	case TelegramParseModeMarkdownV2, TelegramParseModeHTML:
+	default:
+		return nil, fmt.Errorf("%w: '%s'", errUnknownTelegramParseMode, cfg.ParseMode)
+	}
+
+	return &Telegram{TelegramConfig: cfg}, nil
 }
 
 func (t Telegram) Name() string {
-	return "telegram-" + t.ChatID
+	return "telegram-" + t.Chats[0].ChatID
 }
 
 var errUnacceptableResponseFromTelegram = errors.New("unacceptable response from Telegram bot API")
 
+//nolint:tagliatelle // required structure for telegram requests
+type telegramInlineKeyboardButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+//nolint:tagliatelle // required structure for telegram requests
+type telegramReplyMarkup struct {
+	InlineKeyboard [][]telegramInlineKeyboardButton `json:"inline_keyboard"`
+}
+
+//nolint:tagliatelle // required structure for telegram requests
+type telegramLinkPreviewOptions struct {
+	IsDisabled bool `json:"is_disabled"`
+}
+
+// telegramDelivery holds the options shared by all sent messages.
+//
+//nolint:tagliatelle // required structure for telegram requests
+type telegramDelivery struct {
+	ChatID              string               `json:"chat_id"`
+	MessageThreadID     int                  `json:"message_thread_id,omitempty"`
+	ParseMode           string               `json:"parse_mode"`
+	DisableNotification bool                 `json:"disable_notification,omitempty"`
+	ProtectContent      bool                 `json:"protect_content,omitempty"`
+	ReplyMarkup         *telegramReplyMarkup `json:"reply_markup,omitempty"`
+}
+
+//nolint:tagliatelle // required structure for telegram requests
+type telegramMessage struct {
+	telegramDelivery
+
+	Text               string                      `json:"text"`
+	LinkPreviewOptions *telegramLinkPreviewOptions `json:"link_preview_options,omitempty"`
+}
+
+type telegramPhoto struct {
+	telegramDelivery
+
+	Photo   string `json:"photo"`
+	Caption string `json:"caption"`
+}
+
+// Send delivers the story to every configured chat except the ones it was delivered to already.
+// When a chat fails after others got the story, the error is a *PartialDeliveryError listing them.
 func (t Telegram) Send(message Story) error {
-	//nolint:tagliatelle // required structure for telegram requests
-	type inlineKeyboard struct {
-		Text              string `json:"text"`
-		URL               string `json:"url"`
-		SwitchInlineQuery string `json:"switch_inline_query"`
-	}
-
-	//nolint:tagliatelle // required structure for telegram requests
-	type replyMarkup struct {
-		InlineKeyboard [][]inlineKeyboard `json:"inline_keyboard"`
-	}
-
-	// Build message text with optional score
-	text := buildTelegramText(message)
-
-	//nolint:tagliatelle // required structure for telegram requests
-	telegramMessage := struct {
-		ChatID      string      `json:"chat_id"`
-		ParseMode   string      `json:"parse_mode"`
-		Text        string      `json:"text"`
-		ReplyMarkup replyMarkup `json:"reply_markup"`
-	}{
-		ChatID:    t.ChatID,
-		ParseMode: "MarkdownV2",
-		Text:      text,
-		ReplyMarkup: replyMarkup{
-			InlineKeyboard: [][]inlineKeyboard{
-				{{Text: "Read", URL: message.URL, SwitchInlineQuery: ""}},
-			},
-		},
-	}
-
-	requestBody, err := json.Marshal(telegramMessage)
+	buttons := []telegramInlineKeyboardButton{{Text: "Read", URL: message.URL, CallbackData: ""}}
+
+	if t.FeedbackButtons && message.ID != "" {
+		buttons = append(buttons,
+			telegramInlineKeyboardButton{Text: "👍", URL: "", CallbackData: FeedbackCallbackData(message.ID, true)},
+			telegramInlineKeyboardButton{Text: "👎", URL: "", CallbackData: FeedbackCallbackData(message.ID, false)},
+		)
+	}
+
+	replyMarkup := &telegramReplyMarkup{InlineKeyboard: [][]telegramInlineKeyboardButton{buttons}}
+
+	var (
+		deliveredTo []string
+		imageURL    *string // looked up once for all chats, finding it may fetch the story page
+	)
+
+	for _, chat := range t.Chats {
+		if slices.Contains(message.DeliveredTo, chat.ChatID) {
+			continue
+		}
+
+		if imageURL == nil {
+			image := t.storyImage(message)
+			imageURL = &image
+		}
+
+		delivery := t.delivery(chat, chat.threadFor(message))
+		delivery.DisableNotification = message.Scored && message.Score < t.SilentBelowScore
+		delivery.ReplyMarkup = replyMarkup
+
+		err := t.sendToChat(message, *imageURL, delivery)
+		if err != nil {
+			return partialDelivery(deliveredTo, fmt.Errorf("sending to chat '%s': %w", chat.ChatID, err))
+		}
+
+		deliveredTo = append(deliveredTo, chat.ChatID)
+	}
+
+	return nil
+}
+
+// sendToChat sends the story with the image, or as text when there is none or Telegram fails to use it.
+func (t Telegram) sendToChat(message Story, imageURL string, delivery telegramDelivery) error {
+	if imageURL != "" {
+		err := t.sendPhoto(message, imageURL, delivery)
+		if err == nil {
+			return nil
+		}
+
+		var retryAfterErr *RetryAfterError
+		if errors.As(err, &retryAfterErr) {
+			return err
+		}
+
+		// Telegram may be unable to fetch the image, the story is still worth a text message
+	}
+
+	text, err := t.fitText(message, telegramMessageLimit)
+	if err != nil {
+		return err
+	}
+
+	return t.post(context.Background(), "sendMessage", telegramMessage{
+		telegramDelivery:   delivery,
+		Text:               text,
+		LinkPreviewOptions: t.linkPreviewOptions(),
+	}, nil)
+}
+
+// SendDigest delivers the digest as a single message, dropping stories past the first one which
+// would exceed Telegram limits, FitDigest tells which stories remain. Each chat gets only stories
+// which were not delivered to it before, chats having all of them are skipped.
+// When a chat fails after others got the digest, the error is a *PartialDeliveryError listing them.
+func (t Telegram) SendDigest(digest Digest) error {
+	var deliveredTo []string
+
+	for _, chat := range t.Chats {
+		chatDigest := digest.undeliveredTo(chat.ChatID)
+		if len(chatDigest.Groups) == 0 {
+			continue
+		}
+
+		text, _ := buildTelegramDigestText(chatDigest, t.ParseMode)
+
+		err := t.post(context.Background(), "sendMessage", telegramMessage{
+			telegramDelivery:   t.delivery(chat, chat.DefaultThreadID),
+			Text:               text,
+			LinkPreviewOptions: t.linkPreviewOptions(),
+		}, nil)
+		if err != nil {
+			return partialDelivery(deliveredTo, fmt.Errorf("sending digest to chat '%s': %w", chat.ChatID, err))
+		}
+
+		deliveredTo = append(deliveredTo, chat.ChatID)
+	}
+
+	return nil
+}
+
+// fitDigest returns the digest without stories which do not fit into a single message.
+func (t Telegram) fitDigest(digest Digest) Digest {
+	_, rendered := buildTelegramDigestText(digest, t.ParseMode)
+
+	return digest.first(rendered)
+}
+
+func (t Telegram) sendPhoto(message Story, imageURL string, delivery telegramDelivery) error {
+	caption, err := t.fitText(message, telegramCaptionLimit)
+	if err != nil {
+		return err
+	}
+
+	return t.post(context.Background(), "sendPhoto", telegramPhoto{
+		telegramDelivery: delivery,
+		Photo:            imageURL,
+		Caption:          caption,
+	}, nil)
+}
+
+func (t Telegram) delivery(chat TelegramChat, threadID int) telegramDelivery {
+	return telegramDelivery{
+		ChatID:              chat.ChatID,
+		MessageThreadID:     threadID,
+		ParseMode:           t.ParseMode,
+		DisableNotification: false,
+		ProtectContent:      t.ProtectContent,
+		ReplyMarkup:         nil,
+	}
+}
+
+// threadFor picks the forum topic of the story, matching the scoring interest first and the source second.
+func (c TelegramChat) threadFor(message Story) int {
+	if threadID, ok := c.ThreadsByInterest[message.Interest]; ok && message.Interest != "" {
+		return threadID
+	}
+
+	if threadID, ok := c.ThreadsBySource[message.Source]; ok {
+		return threadID
+	}
+
+	return c.DefaultThreadID
+}
+
+// storyImage returns the image to send the story with, empty when it should be sent as text.
+func (t Telegram) storyImage(message Story) string {
+	if !t.SendPhotos {
+		return ""
+	}
+
+	if message.Item.ImageURL != "" {
+		return message.Item.ImageURL
+	}
+
+	if !t.FetchOGImage {
+		return ""
+	}
+
+	imageURL, err := parser.FetchOGImage(message.URL)
+	if err != nil {
+		return ""
+	}
+
+	return imageURL
+}
+
+func (t Telegram) linkPreviewOptions() *telegramLinkPreviewOptions {
+	if !t.DisableLinkPreview {
+		return nil
+	}
+
+	return &telegramLinkPreviewOptions{IsDisabled: true}
+}
+
+// post calls the bot API method, decoding the result into the given value when it is not nil.
+func (t Telegram) post(ctx context.Context, method string, payload, result any) error {
+	requestBody, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("preparing request body: %w", err)
 	}
 
-	requestURL := fmt.Sprintf("https://api.Telegram.org/bot%s/sendMessage", t.BotAPIToken)
+	requestURL := fmt.Sprintf("https://api.Telegram.org/bot%s/%s", t.BotAPIToken, method)
 
-	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, requestURL, bytes.NewBuffer(requestBody))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewBuffer(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
 	//nolint:exhaustruct // no need to set any other fields
@@ -105,9 +331,14 @@ func (t Telegram) Send(message Story) error {
 		return fmt.Errorf("reading response body: %w", err)
 	}
 
+	//nolint:tagliatelle // required structure for telegram responses
 	var telegramResponse struct {
-		OK          bool   `json:"ok"`
-		Description string `json:"description"`
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
 	}
 
 	err = json.Unmarshal(body, &telegramResponse)
@@ -116,56 +347,24 @@ func (t Telegram) Send(message Story) error {
 	}
 
 	if !telegramResponse.OK {
-		return fmt.Errorf("%w: %s", errUnacceptableResponseFromTelegram, telegramResponse.Description)
-	}
+		err = fmt.Errorf("%w: %s", errUnacceptableResponseFromTelegram, telegramResponse.Description)
 
-	return nil
-}
-
-const scoreMultiplier = 100
-
-func buildTelegramText(message Story) string {
-	if message.Score > 0 {
-		return fmt.Sprintf(`*%s*
-📊 Score: %.0f%%
+		if telegramResponse.Parameters.RetryAfter > 0 {
+			return &RetryAfterError{
+				After: time.Duration(telegramResponse.Parameters.RetryAfter) * time.Second,
+				Err:   err,
+			}
+		}
 
-%s`, // empty line is intended
-			escapeTelegramText(message.Title),
-			message.Score*scoreMultiplier,
-			escapeTelegramText(message.URL),
-		)
+		return err
 	}
 
-	return fmt.Sprintf(`*%s*
-
-%s`, // empty line is intended
-		escapeTelegramText(message.Title),
-		escapeTelegramText(message.URL),
-	)
-}
-
-func escapeTelegramText(text string) string {
-	replacer := strings.NewReplacer(
-		"_", "\\_",
-		"*", "\\*",
-		"[", "\\[",
-		"]", "\\]",
-		"(", "\\(",
-		")", "\\)",
-		"~", "\\~",
-		"`", "\\`",
-		">", "\\>",
-		"#", "\\#",
-		"+", "\\+",
-		"-", "\\-",
-		"=", "\\=",
-		"|", "\\|",
-		"{", "\\{",
-		"}", "\\}",
-		".", "\\.",
-		",", "\\,",
-		"!", "\\!",
-	)
+	if result != nil {
+		err = json.Unmarshal(telegramResponse.Result, result)
+		if err != nil {
+			return fmt.Errorf("unmarshaling response result: %w", err)
+		}
+	}
 
-	return replacer.Replace(text)
+	return nil
 }
@@ -0,0 +1,42 @@
+package broadcast_test
+
+import (
+	"mynews/internal/pkg/broadcast"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFitDigestStopsAtFirstOverflow(t *testing.T) {
+	t.Parallel()
+
+	telegram := &broadcast.Telegram{TelegramConfig: broadcast.TelegramConfig{ParseMode: broadcast.TelegramParseModeHTML}}
+
+	digest := broadcast.Digest{Title: "Daily digest", Groups: nil}
+
+	for groupIdx := range 3 {
+		group := broadcast.DigestGroup{Name: "Group " + strconv.Itoa(groupIdx), Stories: nil}
+
+		for storyIdx := range 20 {
+			// long titles of the first group overflow, short ones of later groups would still fit
+			title := "story " + strconv.Itoa(storyIdx)
+			if groupIdx == 0 {
+				title += strings.Repeat(" long", 50)
+			}
+
+			group.Stories = append(group.Stories, broadcast.Story{Title: title, URL: "https://example.com/" + title})
+		}
+
+		digest.Groups = append(digest.Groups, group)
+	}
+
+	fitted := broadcast.FitDigest(telegram, digest)
+
+	if len(fitted.Groups) != 1 {
+		t.Fatalf("expected stories of the first group only, got %d groups", len(fitted.Groups))
+	}
+
+	if stories := len(fitted.Groups[0].Stories); stories == 0 || stories == 20 {
+		t.Errorf("expected part of the first group to fit, got %d stories", stories)
+	}
+}
@@ -0,0 +1,220 @@
+package broadcast
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// TelegramParseModeMarkdownV2 formats messages with Telegram MarkdownV2.
+	TelegramParseModeMarkdownV2 = "MarkdownV2"
+	// TelegramParseModeHTML formats messages with Telegram HTML.
+	TelegramParseModeHTML = "HTML"
+
+	// telegramMessageLimit is the maximum length of a Telegram message text.
+	telegramMessageLimit = 4096
+	// telegramCaptionLimit is the maximum length of a Telegram photo caption.
+	telegramCaptionLimit = 1024
+
+	scoreMultiplier = 100
+)
+
+var errTextTooLong = errors.New("story does not fit into a telegram message")
+
+// render formats the story with the template, or the default layout of the parse mode when it is nil.
+func (t Telegram) render(template *Template, message Story) (string, error) {
+	if template != nil {
+		text, err := template.Render(message)
+		if err != nil {
+			return "", fmt.Errorf("rendering message template: %w", err)
+		}
+
+		return text, nil
+	}
+
+	return buildTelegramText(message, t.ParseMode), nil
+}
+
+// fitText renders the story, shortening its content, summary and title in turn until the text fits within
+// the limit. Shortening the template inputs instead of the rendered text keeps the markup valid.
+// A template too long by itself gives way to the default layout.
+func (t Telegram) fitText(message Story, limit int) (string, error) {
+	template := t.Template
+
+	for {
+		text, err := t.render(template, message)
+		if err != nil {
+			return "", err
+		}
+
+		overflow := telegramLength(text) - limit
+		if overflow <= 0 {
+			return text, nil
+		}
+
+		switch titleLength := utf8.RuneCountInString(message.Title); {
+		case message.Item.Content != "":
+			message.Item.Content = shorten(message.Item.Content, overflow)
+		case message.Item.Summary != "":
+			message.Item.Summary = shorten(message.Item.Summary, overflow)
+		case titleLength > 1:
+			message.Title = truncate(max(titleLength-overflow, 1), message.Title)
+			message.Item.Title = message.Title
+		case template != nil:
+			template = nil
+		default:
+			return "", fmt.Errorf("%w: %d characters over the limit", errTextTooLong, overflow)
+		}
+	}
+}
+
+// shorten cuts the text by the given number of characters, down to nothing.
+func shorten(text string, by int) string {
+	length := utf8.RuneCountInString(text) - by
+	if length <= 1 {
+		return ""
+	}
+
+	return truncate(length, text)
+}
+
+func buildTelegramText(message Story, parseMode string) string {
+	escape, bold := telegramMarkup(parseMode)
+
+	if message.Trending {
+		return "🔥 " + bold(escape(fmt.Sprintf("Trending in %d sources", len(message.AlsoCoveredBy)+1))) + "\n\n" +
+			bold(escape(message.Title)) + "\n\n" + escape(message.URL) + telegramCoverage(message.AlsoCoveredBy, parseMode)
+	}
+
+	if message.Score > 0 {
+		return fmt.Sprintf(`%s
+📊 Score: %s
+
+%s`, // empty line is intended
+			bold(escape(message.Title)),
+			escape(fmt.Sprintf("%.0f%%", message.Score*scoreMultiplier)),
+			escape(message.URL),
+		) + telegramCoverage(message.AlsoCoveredBy, parseMode)
+	}
+
+	return fmt.Sprintf(`%s
+
+%s`, // empty line is intended
+		bold(escape(message.Title)),
+		escape(message.URL),
+	) + telegramCoverage(message.AlsoCoveredBy, parseMode)
+}
+
+// telegramCoverage lists sources of near-duplicates of the story, linking to their versions.
+func telegramCoverage(coverage []Coverage, parseMode string) string {
+	if len(coverage) == 0 {
+		return ""
+	}
+
+	escape, _ := telegramMarkup(parseMode)
+
+	links := make([]string, len(coverage))
+	for idx, covered := range coverage {
+		links[idx] = telegramLink(parseMode, escape(covered.Source), covered.URL)
+	}
+
+	return "\n\n" + escape("Also covered by: ") + strings.Join(links, escape(", "))
+}
+
+// buildTelegramDigestText renders the digest into a single message, returning it along with the number of
+// stories rendered. Rendering stops at the first story which would exceed the message limit.
+func buildTelegramDigestText(digest Digest, parseMode string) (string, int) {
+	escape, bold := telegramMarkup(parseMode)
+
+	var text strings.Builder
+
+	text.WriteString(bold(escape(digest.Title)) + "\n")
+
+	var rendered int
+
+	for _, group := range digest.Groups {
+		// the header goes with the first story of the group, so a group is never left without stories
+		groupHeader := "\n" + bold(escape(group.Name)) + "\n"
+
+		for _, story := range group.Stories {
+			line := groupHeader + "• " + telegramLink(parseMode, escape(story.Title), story.URL)
+			if story.Score > 0 {
+				line += escape(fmt.Sprintf(" (%.0f%%)", story.Score*scoreMultiplier))
+			}
+
+			line += "\n"
+
+			if telegramLength(text.String()+line) > telegramMessageLimit {
+				return text.String(), rendered
+			}
+
+			text.WriteString(line)
+
+			groupHeader = ""
+			rendered++
+		}
+	}
+
+	return text.String(), rendered
+}
+
+// telegramMarkup returns escaping and bold formatting functions of the parse mode.
+func telegramMarkup(parseMode string) (func(string) string, func(string) string) {
+	if parseMode == TelegramParseModeHTML {
+		return html.EscapeString, func(text string) string { return "<b>" + text + "</b>" }
+	}
+
+	return escapeTelegramText, func(text string) string { return "*" + text + "*" }
+}
+
+func telegramLink(parseMode, escapedText, url string) string {
+	if parseMode == TelegramParseModeHTML {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), escapedText)
+	}
+
+	return fmt.Sprintf("[%s](%s)", escapedText, escapeTelegramLinkURL(url))
+}
+
+// escapeTelegramLinkURL escapes the URL part of a MarkdownV2 inline link.
+func escapeTelegramLinkURL(url string) string {
+	return strings.NewReplacer("\\", "\\\\", ")", "\\)").Replace(url)
+}
+
+// escapeTelegramText escapes every character reserved by MarkdownV2, including the backslash itself.
+func escapeTelegramText(text string) string {
+	var escaped strings.Builder
+
+	for _, char := range text {
+		if strings.ContainsRune("\\_*[]()~`>#+-=|{}.!", char) {
+			escaped.WriteRune('\\')
+		}
+
+		escaped.WriteRune(char)
+	}
+
+	return escaped.String()
+}
+
+// telegramLength counts the text the way Telegram does, in UTF-16 code units.
+func telegramLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// cutTelegramText cuts the text to the limit on a character boundary without leaving a dangling escape.
+func cutTelegramText(text string, limit int) string {
+	var length int
+
+	for idx, char := range text {
+		length += len(utf16.Encode([]rune{char}))
+
+		if length > limit {
+			return strings.TrimRight(text[:idx], "\\")
+		}
+	}
+
+	return text
+}
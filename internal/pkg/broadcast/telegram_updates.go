@@ -0,0 +1,135 @@
+package broadcast
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TelegramUpdate is an incoming update received by the bot.
+//
+//nolint:tagliatelle // required structure for telegram responses
+type TelegramUpdate struct {
+	UpdateID      int64                    `json:"update_id"`
+	Message       *TelegramIncomingMessage `json:"message"`
+	CallbackQuery *TelegramCallbackQuery   `json:"callback_query"`
+}
+
+// TelegramCallbackQuery is sent when a user presses an inline keyboard button with callback data.
+type TelegramCallbackQuery struct {
+	ID      string                   `json:"id"`
+	From    TelegramUser             `json:"from"`
+	Message *TelegramIncomingMessage `json:"message"`
+	Data    string                   `json:"data"`
+}
+
+// TelegramIncomingMessage is a message sent to the bot or to a chat the bot is a member of.
+//
+//nolint:tagliatelle // required structure for telegram responses
+type TelegramIncomingMessage struct {
+	MessageID       int64        `json:"message_id"`
+	MessageThreadID int          `json:"message_thread_id"`
+	From            TelegramUser `json:"from"`
+	Chat            struct {
+		ID   int64  `json:"id"`
+		Type string `json:"type"`
+	} `json:"chat"`
+	Text string `json:"text"`
+}
+
+// TelegramUser is the sender of an incoming message.
+type TelegramUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// ChatID returns the chat of the message in the format used by the configuration.
+func (m TelegramIncomingMessage) ChatID() string {
+	return strconv.FormatInt(m.Chat.ID, 10)
+}
+
+// Updates long-polls the bot API for updates starting from the offset.
+// Calling it with the offset of the next update confirms all the previous ones.
+func (t Telegram) Updates(ctx context.Context, offset int64, timeout time.Duration) ([]TelegramUpdate, error) {
+	//nolint:tagliatelle // required structure for telegram requests
+	request := struct {
+		Offset         int64    `json:"offset"`
+		Timeout        int      `json:"timeout"`
+		AllowedUpdates []string `json:"allowed_updates"`
+	}{
+		Offset:         offset,
+		Timeout:        int(timeout.Seconds()),
+		AllowedUpdates: []string{"message", "callback_query"},
+	}
+
+	var updates []TelegramUpdate
+
+	err := t.post(ctx, "getUpdates", request, &updates)
+	if err != nil {
+		return nil, fmt.Errorf("getting updates: %w", err)
+	}
+
+	return updates, nil
+}
+
+// Reply sends a plain text message to the chat and topic of the incoming message.
+func (t Telegram) Reply(to TelegramIncomingMessage, text string) error {
+	//nolint:tagliatelle // required structure for telegram requests
+	request := struct {
+		ChatID          int64  `json:"chat_id"`
+		MessageThreadID int    `json:"message_thread_id,omitempty"`
+		Text            string `json:"text"`
+	}{
+		ChatID:          to.Chat.ID,
+		MessageThreadID: to.MessageThreadID,
+		Text:            cutTelegramText(text, telegramMessageLimit),
+	}
+
+	return t.post(context.Background(), "sendMessage", request, nil)
+}
+
+// AnswerCallback acknowledges the callback query, showing the text as a notification to the user.
+func (t Telegram) AnswerCallback(query TelegramCallbackQuery, text string) error {
+	//nolint:tagliatelle // required structure for telegram requests
+	request := struct {
+		CallbackQueryID string `json:"callback_query_id"`
+		Text            string `json:"text"`
+	}{
+		CallbackQueryID: query.ID,
+		Text:            text,
+	}
+
+	return t.post(context.Background(), "answerCallbackQuery", request, nil)
+}
+
+const (
+	feedbackCallbackPrefix   = "fb:"
+	feedbackCallbackPositive = "+"
+	feedbackCallbackNegative = "-"
+)
+
+// FeedbackCallbackData encodes a story rating as inline button callback data.
+func FeedbackCallbackData(storyID string, positive bool) string {
+	if positive {
+		return feedbackCallbackPrefix + feedbackCallbackPositive + ":" + storyID
+	}
+
+	return feedbackCallbackPrefix + feedbackCallbackNegative + ":" + storyID
+}
+
+// ParseFeedbackCallbackData decodes callback data created by FeedbackCallbackData.
+func ParseFeedbackCallbackData(data string) (string, bool, bool) {
+	rating, ok := strings.CutPrefix(data, feedbackCallbackPrefix)
+	if !ok {
+		return "", false, false
+	}
+
+	sign, storyID, ok := strings.Cut(rating, ":")
+	if !ok || storyID == "" || (sign != feedbackCallbackPositive && sign != feedbackCallbackNegative) {
+		return "", false, false
+	}
+
+	return storyID, sign == feedbackCallbackPositive, true
+}
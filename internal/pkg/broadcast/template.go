@@ -0,0 +1,120 @@
+package broadcast
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// TemplateEngineText renders templates with text/template.
+	TemplateEngineText = "text"
+	// TemplateEngineHTML renders templates with html/template, escaping values automatically.
+	TemplateEngineHTML = "html"
+)
+
+var errUnknownTemplateEngine = errors.New("unknown template engine")
+
+// Template renders a story into a broadcaster message.
+type Template struct {
+	execute func(w io.Writer, data any) error
+}
+
+// NewTemplate parses a user supplied message template for the given engine.
+// Templates are executed with a Story and have access to TemplateFuncs.
+func NewTemplate(engine, text string) (*Template, error) {
+	switch engine {
+	case "", TemplateEngineText:
+		tmpl, err := texttemplate.New("message").Funcs(TemplateFuncs()).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing text template: %w", err)
+		}
+
+		return &Template{execute: tmpl.Execute}, nil
+	case TemplateEngineHTML:
+		tmpl, err := htmltemplate.New("message").Funcs(TemplateFuncs()).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing html template: %w", err)
+		}
+
+		return &Template{execute: tmpl.Execute}, nil
+	default:
+		return nil, fmt.Errorf("%w: '%s'", errUnknownTemplateEngine, engine)
+	}
+}
+
+// Render executes the template with the story.
+func (t *Template) Render(story Story) (string, error) {
+	var out bytes.Buffer
+
+	err := t.execute(&out, story)
+	if err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// TemplateFuncs returns helper functions available in message templates.
+func TemplateFuncs() map[string]any {
+	return map[string]any{
+		"escapeMarkdown": escapeTelegramText,
+		"escapeHTML":     htmltemplate.HTMLEscapeString,
+		"truncate":       truncate,
+		"domain":         domain,
+		"relativeTime":   relativeTime,
+		"percent":        func(score float64) string { return fmt.Sprintf("%.0f%%", score*scoreMultiplier) },
+	}
+}
+
+// truncate shortens the text to at most limit characters, marking the cut with an ellipsis.
+func truncate(limit int, text string) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	if limit <= 1 {
+		return "…"
+	}
+
+	runes := []rune(text)
+
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// domain returns the host of the link without the "www." prefix.
+func domain(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
+
+// relativeTime describes how long ago the time was, e.g. "5m ago".
+func relativeTime(at time.Time) string {
+	if at.IsZero() {
+		return ""
+	}
+
+	elapsed := time.Since(at)
+
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24)) //nolint:mnd // hours in a day
+	}
+}
@@ -0,0 +1,66 @@
+package broadcast_test
+
+import (
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/parser"
+	"testing"
+	"time"
+)
+
+func TestTemplateRender(t *testing.T) {
+	t.Parallel()
+
+	story := broadcast.Story{
+		Title:  "Go 1.30 released!",
+		URL:    "https://www.example.com/go?a=1",
+		Score:  0.8123,
+		Reason: "golang",
+		Source: "example",
+		Item: parser.Item{
+			Title:             "Go 1.30 released!",
+			Link:              "https://www.example.com/go?a=1",
+			PublishedAt:       "",
+			PublishedAtParsed: time.Now().Add(-2 * time.Hour),
+		},
+	}
+
+	tests := []struct {
+		engine, template, expected string
+	}{
+		{
+			engine:   broadcast.TemplateEngineText,
+			template: `{{ .Title | escapeMarkdown }} {{ percent .Score }} {{ domain .URL }} {{ relativeTime .Item.PublishedAtParsed }}`,
+			expected: `Go 1\.30 released\! 81% example.com 2h ago`,
+		},
+		{
+			engine:   broadcast.TemplateEngineText,
+			template: `{{ truncate 6 .Title }}`,
+			expected: `Go 1.…`,
+		},
+		{
+			engine:   broadcast.TemplateEngineHTML,
+			template: `<a href="{{ .URL }}">{{ .Title }}</a>`,
+			expected: `<a href="https://www.example.com/go?a=1">Go 1.30 released!</a>`,
+		},
+	}
+
+	for _, test := range tests {
+		tmpl, err := broadcast.NewTemplate(test.engine, test.template)
+		if err != nil {
+			t.Errorf("parsing '%s': %v", test.template, err)
+
+			continue
+		}
+
+		text, err := tmpl.Render(story)
+		if err != nil {
+			t.Errorf("rendering '%s': %v", test.template, err)
+
+			continue
+		}
+
+		if text != test.expected {
+			t.Errorf("rendering '%s': expected '%s', got '%s'", test.template, test.expected, text)
+		}
+	}
+}
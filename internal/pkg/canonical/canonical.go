@@ -0,0 +1,104 @@
+// Package canonical rewrites story links so different links to the same page compare equal.
+package canonical
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Clean removes tracking parameters, fragments and AMP variants from the link, keeping it usable for readers.
+// Links which do not parse are returned trimmed.
+func Clean(link string) string {
+	link = strings.TrimSpace(link)
+
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return link
+	}
+
+	parsed = fromAMPCache(parsed)
+	parsed.Fragment, parsed.RawFragment = "", ""
+	parsed.Host = strings.TrimPrefix(strings.ToLower(parsed.Host), "amp.")
+
+	query := parsed.Query()
+	for param := range query {
+		if isTrackingParameter(param) {
+			query.Del(param)
+		}
+	}
+
+	parsed.RawQuery = query.Encode() // sorts the parameters as well
+
+	if path := strings.TrimSuffix(parsed.Path, "/"); strings.HasSuffix(path, "/amp") {
+		parsed.Path, parsed.RawPath = strings.TrimSuffix(path, "amp"), ""
+	}
+
+	return parsed.String()
+}
+
+// Key returns the identity of the link: the cleaned link without the scheme, "www.", default ports
+// and a trailing slash.
+func Key(link string) string {
+	cleaned := Clean(link)
+
+	parsed, err := url.Parse(cleaned)
+	if err != nil || parsed.Host == "" {
+		return cleaned
+	}
+
+	host := strings.TrimPrefix(parsed.Hostname(), "www.")
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	key := host + strings.TrimSuffix(parsed.EscapedPath(), "/")
+	if parsed.RawQuery != "" {
+		key += "?" + parsed.RawQuery
+	}
+
+	return key
+}
+
+// fromAMPCache returns the original page of a link served from the Google AMP cache.
+func fromAMPCache(parsed *url.URL) *url.URL {
+	var path string
+
+	switch host := strings.ToLower(parsed.Hostname()); {
+	case (host == "www.google.com" || host == "google.com") && strings.HasPrefix(parsed.Path, "/amp/"):
+		path = strings.TrimPrefix(parsed.Path, "/amp/")
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		path = strings.TrimPrefix(strings.TrimPrefix(parsed.Path, "/c"), "/v")
+		path = strings.TrimPrefix(path, "/")
+	default:
+		return parsed
+	}
+
+	scheme := "http"
+	if rest, ok := strings.CutPrefix(path, "s/"); ok {
+		scheme, path = "https", rest
+	}
+
+	original, err := url.Parse(scheme + "://" + path)
+	if err != nil || original.Host == "" {
+		return parsed
+	}
+
+	original.RawQuery = parsed.RawQuery
+
+	return original
+}
+
+func isTrackingParameter(param string) bool {
+	param = strings.ToLower(param)
+
+	return strings.HasPrefix(param, "utm_") || strings.HasPrefix(param, "_hs") || slices.Contains(trackingParameters, param)
+}
+
+// trackingParameters are known tracking keys only, generic ones like ref or amp may pick the page content.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var trackingParameters = []string{
+	"fbclid", "gclid", "dclid", "msclkid", "yclid", "igshid", "mc_cid", "mc_eid", "ref_src", "ref_url",
+	"cmpid", "ncid", "ocid", "sr_share", "spm", "guccounter", "smid", "at_medium", "at_campaign",
+}
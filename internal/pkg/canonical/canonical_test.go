@@ -0,0 +1,77 @@
+package canonical_test
+
+import (
+	"context"
+	"fmt"
+	"mynews/internal/pkg/canonical"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	expected := "example.com/news/story?id=7"
+
+	for _, link := range []string{
+		"https://example.com/news/story?id=7",
+		"http://www.example.com/news/story/?utm_source=rss&utm_medium=feed&id=7",
+		"https://EXAMPLE.com:443/news/story?id=7&fbclid=abc#comments",
+		"https://amp.example.com/news/story/amp/?id=7",
+		"https://www.google.com/amp/s/example.com/news/story?id=7",
+		"https://example-com.cdn.ampproject.org/c/s/example.com/news/story?id=7",
+	} {
+		if key := canonical.Key(link); key != expected {
+			t.Errorf("%s: expected %s, got %s", link, expected, key)
+		}
+	}
+
+	if cleaned := canonical.Clean("http://example.com/a?utm_campaign=x&b=2&a=1"); cleaned != "http://example.com/a?a=1&b=2" {
+		t.Errorf("unexpected cleaned link %s", cleaned)
+	}
+
+	// generic parameters may select the page content, only known tracking ones are dropped
+	cleaned := canonical.Clean("https://example.com/a?ref=v2&amp=1&gclid=x")
+	if cleaned != "https://example.com/a?amp=1&ref=v2" {
+		t.Errorf("unexpected cleaned link %s", cleaned)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Redirect(w, r, "/story?utm_source=feedproxy", http.StatusFound)
+	})
+	mux.HandleFunc("/story", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `<html><head><link href="/canonical/story" rel="canonical"></head></html>`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	redirects := canonical.NewResolver(true, false, time.Second)
+
+	for range 2 {
+		if resolved := redirects.Resolve(context.Background(), server.URL+"/redirect"); resolved != server.URL+"/story" {
+			t.Fatalf("expected the redirect target, got %s", resolved)
+		}
+	}
+
+	if requests.Load() != 1 {
+		t.Fatalf("expected a cached resolution, got %d requests", requests.Load())
+	}
+
+	discovery := canonical.NewResolver(true, true, time.Second)
+
+	if resolved := discovery.Resolve(context.Background(), server.URL+"/redirect"); resolved != server.URL+"/canonical/story" {
+		t.Fatalf("expected the canonical link, got %s", resolved)
+	}
+}
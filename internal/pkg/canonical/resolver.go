@@ -0,0 +1,163 @@
+package canonical
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds resolving a single link.
+	DefaultTimeout = 10 * time.Second
+
+	// resolvedCacheSize bounds the number of remembered links, the cache is cleared when full.
+	resolvedCacheSize = 20000
+	// maxPageSize is the most of a page read when looking for its canonical link.
+	maxPageSize = 1 << 20
+)
+
+var errBadResponseCode = errors.New("bad response code")
+
+//nolint:gochecknoglobals // patterns are compiled once
+var (
+	linkTagPattern   = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// Resolver follows redirects of links and the canonical links declared by their pages, remembering results.
+type Resolver struct {
+	followRedirects bool
+	discover        bool // look up <link rel="canonical"> of pages
+	client          *http.Client
+
+	mux      *sync.Mutex
+	resolved map[string]string // link -> resolved link
+}
+
+// NewResolver creates a resolver, a zero timeout uses DefaultTimeout.
+func NewResolver(followRedirects, discoverCanonical bool, timeout time.Duration) *Resolver {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Resolver{
+		followRedirects: followRedirects,
+		discover:        discoverCanonical,
+		client: &http.Client{ //nolint:exhaustruct // defaults are fine
+			Timeout: timeout,
+			CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+				if !followRedirects {
+					return http.ErrUseLastResponse
+				}
+
+				if len(via) >= 10 { //nolint:mnd // same limit as the default client
+					return http.ErrUseLastResponse
+				}
+
+				return nil
+			},
+		},
+		mux:      &sync.Mutex{},
+		resolved: make(map[string]string),
+	}
+}
+
+// Resolve returns the cleaned link of the page behind the link. Links which fail to resolve are only cleaned,
+// and resolved again the next time. A nil resolver only cleans links.
+func (r *Resolver) Resolve(ctx context.Context, link string) string {
+	if r == nil || (!r.followRedirects && !r.discover) {
+		return Clean(link)
+	}
+
+	r.mux.Lock()
+	resolved, ok := r.resolved[link]
+	r.mux.Unlock()
+
+	if ok {
+		return resolved
+	}
+
+	resolved, err := r.resolve(ctx, link)
+	if err != nil {
+		return Clean(link)
+	}
+
+	resolved = Clean(resolved)
+
+	r.mux.Lock()
+	if len(r.resolved) >= resolvedCacheSize {
+		clear(r.resolved)
+	}
+
+	r.resolved[link] = resolved
+	r.mux.Unlock()
+
+	return resolved
+}
+
+func (r *Resolver) resolve(ctx context.Context, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to build http request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "Mynews/1.0")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request http request: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", errBadResponseCode
+	}
+
+	final := resp.Request.URL
+
+	if r.discover {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+		if err != nil {
+			return "", fmt.Errorf("reading body: %w", err)
+		}
+
+		if canonical := canonicalLink(string(body), final); canonical != "" {
+			return canonical, nil
+		}
+	}
+
+	return final.String(), nil
+}
+
+// canonicalLink returns the absolute link of the first <link rel="canonical"> of the page, empty when missing.
+func canonicalLink(page string, base *url.URL) string {
+	for _, tag := range linkTagPattern.FindAllString(page, -1) {
+		attributes := make(map[string]string)
+
+		for _, match := range attributePattern.FindAllStringSubmatch(tag, -1) {
+			attributes[strings.ToLower(match[1])] = html.UnescapeString(strings.Trim(match[2], `"'`))
+		}
+
+		if !strings.EqualFold(attributes["rel"], "canonical") || attributes["href"] == "" {
+			continue
+		}
+
+		href, err := base.Parse(attributes["href"])
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https") {
+			return ""
+		}
+
+		return href.String()
+	}
+
+	return ""
+}
@@ -5,7 +5,10 @@ import (
 	"flag"
 	"fmt"
 	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/filter"
 	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/schedule"
+	"mynews/internal/pkg/scorer"
 	"mynews/internal/pkg/storage"
 	"os"
 	"time"
@@ -14,13 +17,41 @@ import (
 var ErrCreatedNewFile = errors.New("created new file")
 
 type Source struct {
+	Name                string // Human readable name, defaults to the host of the URL
 	URL                 string
 	IgnoreStoriesBefore time.Time
 	MustIncludeKeywords []string
 	MustExcludeKeywords []string
-	StatusPage          bool // used when links in feed does not change but timestamp changes
+	StatusPage          bool         // used when links in feed does not change but timestamp changes
+	IDStrategy          string       // What identifies stories of the source, one of the IDStrategy constants
+	Filter              *filter.Expr // Stories not matching are dropped, nil matches all
+	FilterSets          []*FilterSet // Shared rules applying on top of the source ones
+
+	Scoring *ScoringConfig // Overrides the app scoring when set
+}
+
+// FilterSet is a named group of filter rules defined once and referenced by apps and sources.
+// Stories must pass every set applying to them, besides the rules of their source.
+type FilterSet struct {
+	Name                string
+	MustIncludeKeywords []string
+	MustExcludeKeywords []string
+	Filter              *filter.Expr
 }
 
+const (
+	// IDStrategyLink identifies stories by their canonical link.
+	IDStrategyLink = "link"
+	// IDStrategyGUID identifies stories by their RSS guid or Atom id, falling back to the link when missing.
+	IDStrategyGUID = "guid"
+	// IDStrategyLinkPublished identifies stories by their link and publishing time, for status pages.
+	IDStrategyLinkPublished = "link+published"
+	// IDStrategyTitleLink identifies stories by their title and link.
+	IDStrategyTitleLink = "title+link"
+	// IDStrategyContent identifies stories by a hash of their title, summary and content.
+	IDStrategyContent = "content"
+)
+
 type Config struct {
 	SleepDurationBetweenFeedParsing time.Duration
 	SleepDurationBetweenBroadcasts  time.Duration
@@ -30,20 +61,179 @@ type Config struct {
 
 	Apps []App
 
-	Scoring *ScoringConfig
+	// Overlay holds runtime changes of apps made through bot commands
+	Overlay *Overlay
+
+	Scoring *ScoringConfig // Inherited by apps and sources, which may override any of its fields
+
+	EmbeddingCache *EmbeddingCacheConfig // Nil when embeddings are not cached
+
+	Retry RetryConfig
+
+	Links LinksConfig
+
+	FilePath       string // Config file the config was loaded from
+	ReloadOnChange bool   // Reload the config when its file changes, besides on SIGHUP
+}
+
+// LinksConfig controls how story links are resolved before they identify stories.
+// Links are always stripped of tracking parameters and AMP variants.
+type LinksConfig struct {
+	FollowRedirects   bool          // Identify stories by the page their link redirects to, e.g. for feedproxy links
+	DiscoverCanonical bool          // Identify stories by the rel=canonical link of their page
+	Timeout           time.Duration // Bounds resolving a single link, 0 for the default
+}
+
+// RetryConfig controls redelivery of stories which failed to broadcast.
+type RetryConfig struct {
+	MaxAttempts    int           // Attempts before a story is moved to dead letters
+	InitialBackoff time.Duration // Delay after the first failed attempt, doubled on each next one
+	MaxBackoff     time.Duration // Upper bound for the delay between attempts
+}
+
+// EmbeddingCacheConfig controls the on-disk cache of text embeddings used by the embedding scorer.
+type EmbeddingCacheConfig struct {
+	FilePath   string
+	MaxEntries int // Least recently used embeddings are evicted above this size
 }
 
+// ScoringConfig controls story scoring, stories are not scored when it is nil or disabled.
 type ScoringConfig struct {
-	Enabled   bool
-	Provider  string   // "embedding" or "keyword"
-	Interests []string // Topics to score stories against
-	ModelName string   // HuggingFace model name (for embedding provider)
-	ModelDir  string   // Directory to cache models
+	Enabled       bool
+	Provider      string            // "embedding", "keyword", "bm25", "llm" or "composite"
+	Interests     []scorer.Interest // Topics to score stories against
+	AntiInterests []scorer.Interest // Topics lowering the score of stories resembling them
+	ModelName     string            // HuggingFace model name (for embedding provider)
+	ModelDir      string            // Directory to cache models
+
+	FieldWeights *scorer.FieldWeights // Weights of story fields, nil for defaults
+	MaxTokens    int                  // Story fields are truncated to this many tokens, 0 for the default
+	Workers      int                  // Concurrent encoders of the embedding provider, 0 for the number of CPUs
+
+	LLM       *scorer.LLMConfig       // Chat model of the llm provider
+	Composite *scorer.CompositeConfig // Providers combined by the composite provider
 }
 
 type App struct {
-	Sources   []*Source
-	Broadcast broadcast.Broadcast
+	Sources    []*Source
+	Broadcast  broadcast.Broadcast
+	Filter     *filter.Expr    // Stories of all app sources not matching are dropped, nil matches all
+	FilterSets []*FilterSet    // Shared rules applying to all app sources
+	Digest     *DigestConfig   // When set, stories are batched into scheduled digests instead of sent one by one
+	Commands   *CommandsConfig // When set, the Telegram bot of the app accepts management commands
+	Scoring    *ScoringConfig  // Scoring of app sources without their own scoring
+
+	MinScore float64 // Scored stories below are dropped
+	Routes   []Route // Score based routing, stories matching no route go to the app broadcaster
+
+	Dedupe   *DedupeConfig   // When set, near-duplicate stories of the app sources are sent once
+	Trending *TrendingConfig // When set, clusters of near-duplicates growing fast are announced, requires Dedupe
+}
+
+// TrendingConfig controls alerts about news covered by many sources in a short time.
+type TrendingConfig struct {
+	Window     time.Duration       // Period in which the sources must cover the news
+	MinSources int                 // Distinct sources covering the news within the window making it trending
+	Broadcast  broadcast.Broadcast // Broadcaster of trending alerts, nil for the app broadcaster
+}
+
+const (
+	// DedupeKeepFirst keeps the story seen first out of its near-duplicates.
+	DedupeKeepFirst = "first"
+	// DedupeKeepBest keeps the best scored story out of its near-duplicates, as long as the kept one was not sent yet.
+	DedupeKeepBest = "best"
+)
+
+// DedupeConfig controls clustering of near-duplicate stories coming from different sources.
+type DedupeConfig struct {
+	Window              time.Duration // Stories are compared with ones kept within this window
+	TitleSimilarity     float64       // Share of common title words making stories near-duplicates
+	EmbeddingSimilarity float64       // Cosine similarity of title embeddings making stories near-duplicates, 0 to ignore
+	Keep                string        // DedupeKeepFirst or DedupeKeepBest
+	AlsoCoveredBy       bool          // List sources of dropped near-duplicates with the kept story
+}
+
+// Route sends stories within a score range to a dedicated broadcaster or to the app digest.
+type Route struct {
+	MinScore  float64
+	MaxScore  float64             // Exclusive, 0 means no upper bound
+	Digest    bool                // Add matching stories to the app digest
+	Broadcast broadcast.Broadcast // Broadcaster of matching stories, nil for the digest
+}
+
+// Route returns the first route matching the score, nil when stories should go to the app broadcaster.
+func (a App) Route(score float64) *Route {
+	for idx := range a.Routes {
+		if score >= a.Routes[idx].MinScore && (a.Routes[idx].MaxScore == 0 || score < a.Routes[idx].MaxScore) {
+			return &a.Routes[idx]
+		}
+	}
+
+	return nil
+}
+
+// ScoringFor returns the scoring of the source, nil when its stories are not scored.
+func (a App) ScoringFor(source *Source) *ScoringConfig {
+	scoring := a.Scoring
+	if source != nil && source.Scoring != nil {
+		scoring = source.Scoring
+	}
+
+	if scoring == nil || !scoring.Enabled {
+		return nil
+	}
+
+	return scoring
+}
+
+// Scorings returns enabled scorings of the app and its configured sources.
+func (a App) Scorings() []*ScoringConfig {
+	var scorings []*ScoringConfig
+
+	for _, source := range append([]*Source{nil}, a.Sources...) {
+		if scoring := a.ScoringFor(source); scoring != nil {
+			scorings = append(scorings, scoring)
+		}
+	}
+
+	return scorings
+}
+
+// Broadcasters returns the app broadcaster followed by broadcasters of its routes and trending alerts.
+func (a App) Broadcasters() []broadcast.Broadcast {
+	broadcasters := []broadcast.Broadcast{a.Broadcast}
+
+	for _, route := range a.Routes {
+		if route.Broadcast != nil {
+			broadcasters = append(broadcasters, route.Broadcast)
+		}
+	}
+
+	if a.Trending != nil && a.Trending.Broadcast != nil {
+		broadcasters = append(broadcasters, a.Trending.Broadcast)
+	}
+
+	return broadcasters
+}
+
+// CommandsConfig controls the management commands accepted by the Telegram bot of an app.
+type CommandsConfig struct {
+	AdminIDs []int64 // Telegram user IDs allowed to run commands
+}
+
+const (
+	// DigestGroupBySource groups digest stories by the source they came from.
+	DigestGroupBySource = "source"
+	// DigestGroupByReason groups digest stories by the scoring interest they matched.
+	DigestGroupByReason = "reason"
+)
+
+// DigestConfig controls batching of app stories into scheduled summaries.
+type DigestConfig struct {
+	Title    string
+	Schedule schedule.Schedule
+	GroupBy  string // DigestGroupBySource or DigestGroupByReason
+	TopN     int    // Maximum number of stories in a digest, 0 means unlimited
 }
 
 const (
@@ -54,22 +244,40 @@ const (
 	storageFileDefaultLocation         = "$HOME/.config/mynews/data.json"
 
 	defaultSleepDuration = 10 * time.Second
+
+	defaultRetryMaxAttempts    = 5
+	defaultRetryInitialBackoff = 30 * time.Second
+	defaultRetryMaxBackoff     = time.Hour
+
+	defaultDigestTitle = "Digest"
+
+	defaultDedupeWindow          = 24 * time.Hour
+	defaultDedupeTitleSimilarity = 0.6
+
+	defaultTrendingWindow     = time.Hour
+	defaultTrendingMinSources = 3
 )
 
-func New(log *logger.Log) (*Config, error) {
+// New parses config flags from args using the given flag set and loads the config file.
+// Callers may register additional flags on the set before calling New.
+func New(log *logger.Log, flags *flag.FlagSet, args []string) (*Config, error) {
 	var (
 		configFileLocation, storageFileLocation string
 		createSample                            bool
 	)
 
-	flag.StringVar(&configFileLocation, "config", "",
+	flags.StringVar(&configFileLocation, "config", "",
 		fmt.Sprintf("Path to config file. Defaults to '%s'.", configFileDefaultLocation))
 
-	flag.StringVar(&storageFileLocation, "storage", "",
+	flags.StringVar(&storageFileLocation, "storage", "",
 		fmt.Sprintf("Path to storage file. Defaults to '%s'.", storageFileDefaultLocation))
 
-	flag.BoolVar(&createSample, "create", false, `Creates a sample config file.`)
-	flag.Parse()
+	flags.BoolVar(&createSample, "create", false, `Creates a sample config file.`)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("parsing flags: %w", err)
+	}
 
 	if configFileLocation == "" {
 		configFileLocation = configFileDefaultLocation
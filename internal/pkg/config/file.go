@@ -2,11 +2,19 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/canonical"
+	"mynews/internal/pkg/filter"
 	"mynews/internal/pkg/logger"
+	"mynews/internal/pkg/schedule"
+	"mynews/internal/pkg/scorer"
 	"mynews/internal/pkg/storage"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -15,11 +23,22 @@ type fileStructure struct {
 	SleepDurationBetweenBroadcasts  string `json:"sleepDurationBetweenBroadcasts"`
 
 	StorageFilePath string `json:"storageFilePath"`
+	OverlayFilePath string `json:"overlayFilePath,omitempty"` // Defaults to overlay.json next to the storage file
 
 	Elements []fileStructureElement `json:"apps"`
 
 	Scoring *fileStructureScoring `json:"scoring,omitempty"`
 
+	EmbeddingCache *fileStructureEmbeddingCache `json:"embeddingCache,omitempty"` // Enabled unless disabled explicitly
+
+	Retry *fileStructureRetry `json:"retry,omitempty"`
+
+	Links *fileStructureLinks `json:"links,omitempty"`
+
+	FilterSets map[string]fileStructureFilterSet `json:"filterSets,omitempty"` // Referenced by name from apps and sources
+
+	ReloadOnChange bool `json:"reloadOnChange,omitempty"` // Reload the config when the file changes, besides on SIGHUP
+
 	// Used for backwards compatibility reasons
 	// Deprecated: will be removed in v2
 
@@ -30,12 +49,127 @@ type fileStructure struct {
 	LegacySources []fileStructureSource `json:"sources"`
 }
 
+// fileStructureScoring is the global scoring block, apps and sources inherit fields they leave unset.
 type fileStructureScoring struct {
-	Enabled   bool     `json:"enabled"`
-	Provider  string   `json:"provider"`  // "embedding" or "keyword"
-	Interests []string `json:"interests"` // Topics to score stories against
-	ModelName string   `json:"modelName,omitempty"`
-	ModelDir  string   `json:"modelDir,omitempty"`
+	Enabled       *bool                   `json:"enabled,omitempty"`
+	Provider      string                  `json:"provider,omitempty"`      // "embedding", "keyword", "bm25", "llm" or "composite"
+	Interests     []fileStructureInterest `json:"interests,omitempty"`     // Topics to score stories against
+	AntiInterests []fileStructureInterest `json:"antiInterests,omitempty"` // Topics lowering the score
+	ModelName     string                  `json:"modelName,omitempty"`
+	ModelDir      string                  `json:"modelDir,omitempty"`
+
+	FieldWeights *fileStructureFieldWeights `json:"fieldWeights,omitempty"`
+	MaxTokens    int                        `json:"maxTokens,omitempty"` // Story fields are truncated to this many tokens
+	Workers      int                        `json:"workers,omitempty"`   // Concurrent encoders, defaults to the number of CPUs
+
+	LLM       *fileStructureLLM       `json:"llm,omitempty"`       // Replaces the inherited block as a whole
+	Composite *fileStructureComposite `json:"composite,omitempty"` // Replaces the inherited block as a whole
+}
+
+// fileStructureComposite combines several providers scoring the same interests.
+type fileStructureComposite struct {
+	Strategy  string                         `json:"strategy,omitempty"`  // "max", "weighted" (default) or "gate"
+	GateScore float64                        `json:"gateScore,omitempty"` // Score of the first scorer letting stories through
+	Scorers   []fileStructureCompositeMember `json:"scorers"`
+}
+
+type fileStructureCompositeMember struct {
+	Provider string  `json:"provider"`
+	Weight   float64 `json:"weight,omitempty"`
+}
+
+// fileStructureLLM configures the chat model of the llm provider.
+type fileStructureLLM struct {
+	Endpoint string `json:"endpoint"` // Base URL of an OpenAI-compatible API
+	Model    string `json:"model"`
+	APIKey   string `json:"apiKey,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`  // Per request, defaults to a minute
+	Fallback string `json:"fallback,omitempty"` // Provider used when the model fails
+}
+
+// fileStructureFieldWeights controls how much each story field contributes to its score.
+type fileStructureFieldWeights struct {
+	Title      float64 `json:"title"`
+	Summary    float64 `json:"summary"`
+	Content    float64 `json:"content"`
+	Categories float64 `json:"categories"`
+}
+
+// fileStructureInterest is either a plain topic or an object with its weight and minimum similarity.
+type fileStructureInterest struct {
+	Text     string  `json:"text"`
+	Weight   float64 `json:"weight,omitempty"`
+	MinScore float64 `json:"minScore,omitempty"`
+}
+
+func (fi *fileStructureInterest) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		fi.Weight, fi.MinScore = 0, 0
+
+		err := json.Unmarshal(data, &fi.Text)
+		if err != nil {
+			return fmt.Errorf("decoding interest: %w", err)
+		}
+
+		return nil
+	}
+
+	type plainInterest fileStructureInterest
+
+	err := json.Unmarshal(data, (*plainInterest)(fi))
+	if err != nil {
+		return fmt.Errorf("decoding interest: %w", err)
+	}
+
+	return nil
+}
+
+func (fi fileStructureInterest) MarshalJSON() ([]byte, error) {
+	type plainInterest fileStructureInterest
+
+	if fi.Weight == 0 && fi.MinScore == 0 {
+		return json.Marshal(fi.Text) //nolint:wrapcheck // plain string encoding
+	}
+
+	return json.Marshal(plainInterest(fi)) //nolint:wrapcheck // plain struct encoding
+}
+
+func interestsToConfig(interests []fileStructureInterest) []scorer.Interest {
+	if interests == nil {
+		return nil
+	}
+
+	converted := make([]scorer.Interest, len(interests))
+	for idx, interest := range interests {
+		converted[idx] = scorer.Interest{Text: interest.Text, Weight: interest.Weight, MinScore: interest.MinScore}
+	}
+
+	return converted
+}
+
+type fileStructureEmbeddingCache struct {
+	Enabled    *bool  `json:"enabled,omitempty"`
+	FilePath   string `json:"filePath,omitempty"` // Defaults to embeddings.cache next to the storage file
+	MaxEntries int    `json:"maxEntries,omitempty"`
+}
+
+type fileStructureRetry struct {
+	MaxAttempts    int    `json:"maxAttempts"`
+	InitialBackoff string `json:"initialBackoff"`
+	MaxBackoff     string `json:"maxBackoff"`
+}
+
+type fileStructureLinks struct {
+	FollowRedirects   bool   `json:"followRedirects"`
+	DiscoverCanonical bool   `json:"discoverCanonical"`
+	Timeout           string `json:"timeout,omitempty"` // Per link, defaults to 10s
+}
+
+// fileStructureFilterSet holds filter rules shared by the apps and sources referencing it.
+type fileStructureFilterSet struct {
+	MustIncludeAnyOf []string `json:"mustIncludeAnyOf,omitempty"`
+	MustExcludeAnyOf []string `json:"mustExcludeAnyOf,omitempty"`
+	Filter           string   `json:"filter,omitempty"`
 }
 
 type fileStructureElement struct {
@@ -43,15 +177,94 @@ type fileStructureElement struct {
 	TelegramBotAPIToken string `json:"telegramBotAPIToken"`
 	TelegramChatID      string `json:"telegramChatID"`
 
+	TelegramParseMode          string `json:"telegramParseMode,omitempty"` // "MarkdownV2" (default) or "HTML"
+	TelegramDisableLinkPreview bool   `json:"telegramDisableLinkPreview,omitempty"`
+	TelegramSendPhotos         bool   `json:"telegramSendPhotos,omitempty"`
+	TelegramFetchOGImage       bool   `json:"telegramFetchOGImage,omitempty"`
+
+	TelegramChats            []fileStructureTelegramChat `json:"telegramChats,omitempty"` // Chats besides telegramChatID
+	TelegramSilentBelowScore float64                     `json:"telegramSilentBelowScore,omitempty"`
+	TelegramProtectContent   bool                        `json:"telegramProtectContent,omitempty"`
+
+	TelegramCommands *fileStructureCommands `json:"telegramCommands,omitempty"`
+	TelegramFeedback bool                   `json:"telegramFeedback,omitempty"` // 👍/👎 buttons retraining the scorer
+
+	Digest *fileStructureDigest `json:"digest,omitempty"`
+
+	Filter     string   `json:"filter,omitempty"`     // Filter expression stories of all app sources must match
+	FilterSets []string `json:"filterSets,omitempty"` // Names of shared filter sets applying to all app sources
+
+	Scoring *fileStructureScoring `json:"scoring,omitempty"`
+
+	MinScore float64              `json:"minScore,omitempty"` // Scored stories below are dropped
+	Routes   []fileStructureRoute `json:"routes,omitempty"`   // First matching route wins
+
+	Dedupe   *fileStructureDedupe   `json:"dedupe,omitempty"`
+	Trending *fileStructureTrending `json:"trending,omitempty"` // Requires dedupe
+
+	Template       string `json:"template,omitempty"`       // Go template used to render each story
+	TemplateFile   string `json:"templateFile,omitempty"`   // Path to a file holding the template
+	TemplateEngine string `json:"templateEngine,omitempty"` // "text" (default) or "html"
+
 	Sources []fileStructureSource `json:"sources"`
 }
 
+type fileStructureTelegramChat struct {
+	ChatID            string         `json:"chatID"`
+	DefaultThreadID   int            `json:"defaultThreadID,omitempty"`
+	ThreadsBySource   map[string]int `json:"threadsBySource,omitempty"`   // source name -> forum topic ID
+	ThreadsByInterest map[string]int `json:"threadsByInterest,omitempty"` // scoring interest -> forum topic ID
+}
+
+type fileStructureCommands struct {
+	Enabled  bool    `json:"enabled"`
+	AdminIDs []int64 `json:"adminIDs"`
+}
+
+type fileStructureDigest struct {
+	Enabled  bool   `json:"enabled"`
+	Title    string `json:"title,omitempty"`
+	Schedule string `json:"schedule"` // cron expression, e.g. "0 8 * * *", or "08:00"
+	Timezone string `json:"timezone,omitempty"`
+	GroupBy  string `json:"groupBy,omitempty"` // "source" or "reason"
+	TopN     int    `json:"topN,omitempty"`
+}
+
+type fileStructureDedupe struct {
+	Enabled             bool    `json:"enabled"`
+	Window              string  `json:"window,omitempty"`              // Defaults to 24h
+	TitleSimilarity     float64 `json:"titleSimilarity,omitempty"`     // Share of common title words, defaults to 0.6
+	EmbeddingSimilarity float64 `json:"embeddingSimilarity,omitempty"` // Requires the embedding scoring provider
+	Keep                string  `json:"keep,omitempty"`                // "first" (default) or "best"
+	AlsoCoveredBy       bool    `json:"alsoCoveredBy,omitempty"`
+}
+
+type fileStructureTrending struct {
+	Enabled    bool                  `json:"enabled"`
+	Window     string                `json:"window,omitempty"`     // Defaults to 1h
+	MinSources int                   `json:"minSources,omitempty"` // Defaults to 3
+	Broadcast  *fileStructureElement `json:"broadcast,omitempty"`  // Defaults to the app broadcaster
+}
+
+type fileStructureRoute struct {
+	MinScore  float64               `json:"minScore"`
+	MaxScore  float64               `json:"maxScore,omitempty"` // Exclusive, 0 means no upper bound
+	Digest    bool                  `json:"digest,omitempty"`   // Add matching stories to the app digest
+	Broadcast *fileStructureElement `json:"broadcast,omitempty"`
+}
+
 type fileStructureSource struct {
+	Name                string   `json:"name,omitempty"`
 	URL                 string   `json:"url"`
 	IgnoreStoriesBefore string   `json:"ignoreStoriesBefore"`
 	MustIncludeAnyOf    []string `json:"mustIncludeAnyOf"`
 	MustExcludeAnyOf    []string `json:"mustExcludeAnyOf"`
-	StatusPage          bool     `json:"statusPage"`
+	StatusPage          bool     `json:"statusPage"`           // Same as "idStrategy": "link+published"
+	IDStrategy          string   `json:"idStrategy,omitempty"` // "link" (default), "guid", "link+published", "title+link" or "content"
+	Filter              string   `json:"filter,omitempty"`     // Filter expression stories must match
+	FilterSets          []string `json:"filterSets,omitempty"` // Names of shared filter sets
+
+	Scoring *fileStructureScoring `json:"scoring,omitempty"`
 }
 
 func fromFile(configFilePath, storageFilePath string, log *logger.Log) (*Config, error) {
@@ -62,6 +275,22 @@ func fromFile(configFilePath, storageFilePath string, log *logger.Log) (*Config,
 		return nil, fmt.Errorf("file '%s' does not exist: %w", configFilePath, err)
 	}
 
+	file, err := readFile(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := file.toConfig(storageFilePath, log, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	config.FilePath = configFilePath
+
+	return config, nil
+}
+
+func readFile(configFilePath string) (*fileStructure, error) {
 	configFile, err := os.Open(configFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("opening config file: %w", err)
@@ -78,11 +307,14 @@ func fromFile(configFilePath, storageFilePath string, log *logger.Log) (*Config,
 		return nil, fmt.Errorf("decoding config file (legacy): %w", err)
 	}
 
-	return file.toConfig(storageFilePath, log)
+	return &file, nil
 }
 
+// toConfig converts the file into a config. A reloaded file gets the previous config, whose storage
+// and overlay it keeps instead of loading them again.
+//
 //nolint:cyclop,funlen // allow higher complexity on config setup for now
-func (f *fileStructure) toConfig(storageFilePath string, log *logger.Log) (*Config, error) {
+func (f *fileStructure) toConfig(storageFilePath string, log *logger.Log, previous *Config) (*Config, error) {
 	var (
 		config Config
 		err    error
@@ -98,6 +330,8 @@ func (f *fileStructure) toConfig(storageFilePath string, log *logger.Log) (*Conf
 		return nil, fmt.Errorf("invalid feed parsing sleep duration format: %w", err)
 	}
 
+	config.ReloadOnChange = f.ReloadOnChange
+
 	config.Store = storage.New()
 	config.StorageFilePath = f.StorageFilePath
 
@@ -117,6 +351,32 @@ func (f *fileStructure) toConfig(storageFilePath string, log *logger.Log) (*Conf
 		config.SleepDurationBetweenBroadcasts = defaultSleepDuration
 	}
 
+	overlayPath := f.OverlayFilePath
+	if overlayPath == "" {
+		overlayPath = overlayFilePath(config.StorageFilePath)
+	}
+
+	if previous == nil {
+		config.Overlay, err = newOverlay(overlayPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config overlay: %w", err)
+		}
+	} else {
+		config.Store, config.StorageFilePath, config.Overlay = previous.Store, previous.StorageFilePath, previous.Overlay
+	}
+
+	config.EmbeddingCache = f.EmbeddingCache.toConfig(config.StorageFilePath)
+
+	config.Retry, err = f.Retry.toConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry config: %w", err)
+	}
+
+	config.Links, err = f.Links.toConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid links config: %w", err)
+	}
+
 	if len(f.Elements) == 0 {
 		f.Elements = append(f.Elements, fileStructureElement{
 			BroadcastType:       f.LegacyBroadcastType,
@@ -126,10 +386,20 @@ func (f *fileStructure) toConfig(storageFilePath string, log *logger.Log) (*Conf
 		})
 	}
 
+	config.Scoring, err = f.Scoring.inherit(nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scoring config: %w", err)
+	}
+
+	filterSets, err := filterSetsToConfig(f.FilterSets)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, fe := range f.Elements {
 		var elementConfig App
 
-		elementConfig, err = fe.prepareConfigElement(log)
+		elementConfig, err = fe.prepareConfigElement(config.Scoring, filterSets, log)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse config element: %w", err)
 		}
@@ -137,7 +407,7 @@ func (f *fileStructure) toConfig(storageFilePath string, log *logger.Log) (*Conf
 		config.Apps = append(config.Apps, elementConfig)
 	}
 
-	if len(config.Apps) == 0 {
+	if len(config.Apps) == 0 || previous != nil {
 		return &config, nil
 	}
 
@@ -146,18 +416,205 @@ func (f *fileStructure) toConfig(storageFilePath string, log *logger.Log) (*Conf
 		return nil, fmt.Errorf("failed to recover data from file: %w", err)
 	}
 
-	// Parse scoring config
-	if f.Scoring != nil && f.Scoring.Enabled {
-		config.Scoring = &ScoringConfig{
-			Enabled:   f.Scoring.Enabled,
-			Provider:  f.Scoring.Provider,
-			Interests: f.Scoring.Interests,
-			ModelName: f.Scoring.ModelName,
-			ModelDir:  f.Scoring.ModelDir,
+	return &config, nil
+}
+
+// inherit applies the scoring block on top of the parent scoring, nil when neither is set.
+func (fs *fileStructureScoring) inherit(parent *ScoringConfig) (*ScoringConfig, error) {
+	if fs == nil {
+		return parent, nil
+	}
+
+	scoring := ScoringConfig{
+		Enabled:       false,
+		Provider:      "",
+		Interests:     nil,
+		AntiInterests: nil,
+		ModelName:     "",
+		ModelDir:      "",
+		FieldWeights:  nil,
+		MaxTokens:     0,
+		Workers:       0,
+		LLM:           nil,
+		Composite:     nil,
+	}
+
+	if parent != nil {
+		scoring = *parent
+	}
+
+	if fs.Enabled != nil {
+		scoring.Enabled = *fs.Enabled
+	}
+
+	if fs.Provider != "" {
+		scoring.Provider = fs.Provider
+	}
+
+	if fs.Interests != nil {
+		scoring.Interests = interestsToConfig(fs.Interests)
+	}
+
+	if fs.AntiInterests != nil {
+		scoring.AntiInterests = interestsToConfig(fs.AntiInterests)
+	}
+
+	if fs.ModelName != "" {
+		scoring.ModelName = fs.ModelName
+	}
+
+	if fs.ModelDir != "" {
+		scoring.ModelDir = fs.ModelDir
+	}
+
+	if fs.FieldWeights != nil {
+		scoring.FieldWeights = &scorer.FieldWeights{
+			Title:      fs.FieldWeights.Title,
+			Summary:    fs.FieldWeights.Summary,
+			Content:    fs.FieldWeights.Content,
+			Categories: fs.FieldWeights.Categories,
 		}
 	}
 
-	return &config, nil
+	if fs.MaxTokens > 0 {
+		scoring.MaxTokens = fs.MaxTokens
+	}
+
+	if fs.Workers > 0 {
+		scoring.Workers = fs.Workers
+	}
+
+	if fs.LLM != nil {
+		var err error
+
+		scoring.LLM, err = fs.LLM.toConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fs.Composite != nil {
+		scoring.Composite = fs.Composite.toConfig()
+	}
+
+	return &scoring, nil
+}
+
+func (fc *fileStructureComposite) toConfig() *scorer.CompositeConfig {
+	composite := scorer.CompositeConfig{
+		Strategy:  fc.Strategy,
+		Members:   make([]scorer.CompositeMember, len(fc.Scorers)),
+		GateScore: fc.GateScore,
+	}
+
+	for idx, member := range fc.Scorers {
+		composite.Members[idx] = scorer.CompositeMember{Provider: member.Provider, Weight: member.Weight}
+	}
+
+	return &composite
+}
+
+func (fl *fileStructureLLM) toConfig() (*scorer.LLMConfig, error) {
+	llm := scorer.LLMConfig{
+		Endpoint: fl.Endpoint,
+		Model:    fl.Model,
+		APIKey:   fl.APIKey,
+		Timeout:  0,
+		Fallback: fl.Fallback,
+	}
+
+	if fl.Timeout != "" {
+		var err error
+
+		llm.Timeout, err = time.ParseDuration(fl.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid llm timeout duration format: %w", err)
+		}
+	}
+
+	return &llm, nil
+}
+
+func (fc *fileStructureEmbeddingCache) toConfig(storageFilePath string) *EmbeddingCacheConfig {
+	cache := EmbeddingCacheConfig{
+		FilePath:   filepath.Join(filepath.Dir(storageFilePath), "embeddings.cache"),
+		MaxEntries: scorer.DefaultEmbeddingCacheSize,
+	}
+
+	if fc == nil {
+		return &cache
+	}
+
+	if fc.Enabled != nil && !*fc.Enabled {
+		return nil
+	}
+
+	if fc.FilePath != "" {
+		cache.FilePath = fc.FilePath
+	}
+
+	if fc.MaxEntries > 0 {
+		cache.MaxEntries = fc.MaxEntries
+	}
+
+	return &cache
+}
+
+func (fl *fileStructureLinks) toConfig() (LinksConfig, error) {
+	if fl == nil {
+		return LinksConfig{FollowRedirects: false, DiscoverCanonical: false, Timeout: 0}, nil
+	}
+
+	links := LinksConfig{
+		FollowRedirects:   fl.FollowRedirects,
+		DiscoverCanonical: fl.DiscoverCanonical,
+		Timeout:           0,
+	}
+
+	if fl.Timeout != "" {
+		var err error
+
+		links.Timeout, err = time.ParseDuration(fl.Timeout)
+		if err != nil {
+			return LinksConfig{}, fmt.Errorf("invalid timeout duration format: %w", err)
+		}
+	}
+
+	return links, nil
+}
+
+func (fr *fileStructureRetry) toConfig() (RetryConfig, error) {
+	retry := RetryConfig{
+		MaxAttempts:    defaultRetryMaxAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+	}
+
+	if fr == nil {
+		return retry, nil
+	}
+
+	if fr.MaxAttempts > 0 {
+		retry.MaxAttempts = fr.MaxAttempts
+	}
+
+	var err error
+
+	if fr.InitialBackoff != "" {
+		retry.InitialBackoff, err = time.ParseDuration(fr.InitialBackoff)
+		if err != nil {
+			return RetryConfig{}, fmt.Errorf("invalid initial backoff duration format: %w", err)
+		}
+	}
+
+	if fr.MaxBackoff != "" {
+		retry.MaxBackoff, err = time.ParseDuration(fr.MaxBackoff)
+		if err != nil {
+			return RetryConfig{}, fmt.Errorf("invalid max backoff duration format: %w", err)
+		}
+	}
+
+	return retry, nil
 }
 
 func createSampleFile(filePath string) error {
@@ -175,18 +632,28 @@ func createSampleFile(filePath string) error {
 
 	sources := []fileStructureSource{
 		{
+			Name:                "",
 			URL:                 "https://hnrss.org/newest.atom",
 			IgnoreStoriesBefore: time.Date(2020, 4, 20, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
 			MustIncludeAnyOf:    []string{"linux", "golang", "musk"},
 			MustExcludeAnyOf:    []string{"windows", "trump", "apple"},
 			StatusPage:          false,
+			IDStrategy:          "",
+			Filter:              "",
+			FilterSets:          nil,
+			Scoring:             nil,
 		},
 		{
+			Name:                "",
 			URL:                 "https://hnrss.org/newest.atom",
 			IgnoreStoriesBefore: time.Hour.String(),
 			MustIncludeAnyOf:    nil,
 			MustExcludeAnyOf:    nil,
 			StatusPage:          false,
+			IDStrategy:          "",
+			Filter:              "",
+			FilterSets:          nil,
+			Scoring:             nil,
 		},
 	}
 
@@ -196,24 +663,97 @@ func createSampleFile(filePath string) error {
 		//nolint:mnd // allow fore defaults
 		SleepDurationBetweenBroadcasts: (time.Second * 10).String(),
 		StorageFilePath:                "",
+		OverlayFilePath:                "",
 		Elements: []fileStructureElement{
 			{
-				BroadcastType:       "stdout",
-				Sources:             sources,
-				TelegramBotAPIToken: "",
-				TelegramChatID:      "",
+				BroadcastType:              "stdout",
+				Sources:                    sources,
+				TelegramBotAPIToken:        "",
+				TelegramChatID:             "",
+				Template:                   "",
+				TelegramParseMode:          "",
+				TelegramDisableLinkPreview: false,
+				TelegramSendPhotos:         false,
+				TelegramFetchOGImage:       false,
+				TelegramChats:              nil,
+				TelegramSilentBelowScore:   0,
+				TelegramProtectContent:     false,
+				TelegramCommands:           nil,
+				TelegramFeedback:           false,
+				TemplateFile:               "",
+				TemplateEngine:             "",
+				Filter:                     "",
+				FilterSets:                 []string{"noPromotions"},
+				Scoring:                    nil,
+				MinScore:                   0,
+				Routes:                     nil,
+				Dedupe: &fileStructureDedupe{
+					Enabled:             false,
+					Window:              defaultDedupeWindow.String(),
+					TitleSimilarity:     defaultDedupeTitleSimilarity,
+					EmbeddingSimilarity: 0,
+					Keep:                DedupeKeepFirst,
+					AlsoCoveredBy:       true,
+				},
+				Trending: &fileStructureTrending{
+					Enabled:    false,
+					Window:     defaultTrendingWindow.String(),
+					MinSources: defaultTrendingMinSources,
+					Broadcast:  nil,
+				},
+				Digest: &fileStructureDigest{
+					Enabled:  false,
+					Title:    defaultDigestTitle,
+					Schedule: "0 8 * * *",
+					Timezone: "UTC",
+					GroupBy:  DigestGroupBySource,
+					TopN:     10, //nolint:mnd // allow for defaults
+				},
 			},
 		},
 		Scoring: &fileStructureScoring{
-			Enabled:  false,
+			Enabled:  new(bool),
 			Provider: "embedding",
-			Interests: []string{
-				"artificial intelligence and machine learning",
-				"geopolitical conflicts and international relations",
-				"stock market and financial technology",
+			Interests: []fileStructureInterest{
+				{Text: "artificial intelligence and machine learning", Weight: 0, MinScore: 0},
+				{Text: "geopolitical conflicts and international relations", Weight: 0, MinScore: 0},
+				{Text: "stock market and financial technology", Weight: 0, MinScore: 0},
+			},
+			AntiInterests: nil,
+			ModelName:     "",
+			ModelDir:      "",
+			FieldWeights: &fileStructureFieldWeights{
+				Title:      scorer.DefaultFieldWeights.Title,
+				Summary:    scorer.DefaultFieldWeights.Summary,
+				Content:    scorer.DefaultFieldWeights.Content,
+				Categories: scorer.DefaultFieldWeights.Categories,
+			},
+			MaxTokens: scorer.DefaultMaxTokens,
+			Workers:   0,
+			LLM:       nil,
+			Composite: nil,
+		},
+		EmbeddingCache: &fileStructureEmbeddingCache{
+			Enabled:    nil,
+			FilePath:   "",
+			MaxEntries: scorer.DefaultEmbeddingCacheSize,
+		},
+		Retry: &fileStructureRetry{
+			MaxAttempts:    defaultRetryMaxAttempts,
+			InitialBackoff: defaultRetryInitialBackoff.String(),
+			MaxBackoff:     defaultRetryMaxBackoff.String(),
+		},
+		Links: &fileStructureLinks{
+			FollowRedirects:   false,
+			DiscoverCanonical: false,
+			Timeout:           canonical.DefaultTimeout.String(),
+		},
+		FilterSets: map[string]fileStructureFilterSet{
+			"noPromotions": {
+				MustIncludeAnyOf: nil,
+				MustExcludeAnyOf: []string{"sponsored", "webinar", "podcast"},
+				Filter:           `!(category in ["sponsored", "advertisement"])`,
 			},
-			ModelName: "",
-			ModelDir:  "",
 		},
 		LegacyBroadcastType:       "",
 		LegacyTelegramBotAPIToken: "",
@@ -232,21 +772,58 @@ func createSampleFile(filePath string) error {
 	return nil
 }
 
-func (fe fileStructureElement) prepareConfigElement(log *logger.Log) (App, error) {
+//nolint:cyclop,funlen // allow higher complexity on config setup for now
+func (fe fileStructureElement) prepareConfigElement(
+	scoring *ScoringConfig,
+	filterSets map[string]*FilterSet,
+	log *logger.Log,
+) (App, error) {
 	var (
 		cfg App
 		err error
 	)
 
+	cfg.Scoring, err = fe.Scoring.inherit(scoring)
+	if err != nil {
+		return App{}, fmt.Errorf("invalid scoring config: %w", err)
+	}
+
 	cfg.Sources = make([]*Source, len(fe.Sources))
 
 	for sourceIdx := range fe.Sources {
 		cfg.Sources[sourceIdx] = &Source{
+			Name:                sourceName(fe.Sources[sourceIdx]),
 			URL:                 fe.Sources[sourceIdx].URL,
 			IgnoreStoriesBefore: time.Time{},
 			MustIncludeKeywords: fe.Sources[sourceIdx].MustIncludeAnyOf,
 			MustExcludeKeywords: fe.Sources[sourceIdx].MustExcludeAnyOf,
-			StatusPage:          false,
+			StatusPage:          fe.Sources[sourceIdx].StatusPage,
+			IDStrategy:          "",
+			Filter:              nil,
+			FilterSets:          nil,
+			Scoring:             nil,
+		}
+
+		cfg.Sources[sourceIdx].Filter, err = parseFilter(fe.Sources[sourceIdx].Filter)
+		if err != nil {
+			return App{}, fmt.Errorf("invalid filter of source %s: %w", fe.Sources[sourceIdx].URL, err)
+		}
+
+		cfg.Sources[sourceIdx].FilterSets, err = lookupFilterSets(filterSets, fe.Sources[sourceIdx].FilterSets)
+		if err != nil {
+			return App{}, fmt.Errorf("invalid source %s: %w", fe.Sources[sourceIdx].URL, err)
+		}
+
+		cfg.Sources[sourceIdx].IDStrategy, err = fe.Sources[sourceIdx].idStrategy()
+		if err != nil {
+			return App{}, fmt.Errorf("invalid source %s: %w", fe.Sources[sourceIdx].URL, err)
+		}
+
+		if fe.Sources[sourceIdx].Scoring != nil {
+			cfg.Sources[sourceIdx].Scoring, err = fe.Sources[sourceIdx].Scoring.inherit(cfg.Scoring)
+			if err != nil {
+				return App{}, fmt.Errorf("invalid scoring config of source %s: %w", fe.Sources[sourceIdx].URL, err)
+			}
 		}
 
 		cfg.Sources[sourceIdx].IgnoreStoriesBefore, err = time.Parse(time.RFC3339, fe.Sources[sourceIdx].IgnoreStoriesBefore)
@@ -261,16 +838,376 @@ func (fe fileStructureElement) prepareConfigElement(log *logger.Log) (App, error
 		}
 	}
 
-	cfg.Broadcast = broadcast.NewStdOutClient()
+	cfg.Filter, err = parseFilter(fe.Filter)
+	if err != nil {
+		return App{}, fmt.Errorf("invalid app filter: %w", err)
+	}
 
-	if fe.BroadcastType == "TELEGRAM" {
-		telegramClient, err := broadcast.NewTelegramClient(fe.TelegramBotAPIToken, fe.TelegramChatID)
-		if err != nil {
-			return App{}, fmt.Errorf("failed to create telegram client: %w", err)
+	cfg.FilterSets, err = lookupFilterSets(filterSets, fe.FilterSets)
+	if err != nil {
+		return App{}, err
+	}
+
+	cfg.Digest, err = fe.Digest.toConfig()
+	if err != nil {
+		return App{}, fmt.Errorf("invalid digest config: %w", err)
+	}
+
+	cfg.Broadcast, err = fe.broadcaster()
+	if err != nil {
+		return App{}, err
+	}
+
+	cfg.MinScore = fe.MinScore
+
+	cfg.Routes, err = fe.routes(cfg.Digest != nil)
+	if err != nil {
+		return App{}, fmt.Errorf("invalid routes: %w", err)
+	}
+
+	cfg.Dedupe, err = fe.Dedupe.toConfig()
+	if err != nil {
+		return App{}, fmt.Errorf("invalid dedupe config: %w", err)
+	}
+
+	cfg.Trending, err = fe.Trending.toConfig()
+	if err != nil {
+		return App{}, fmt.Errorf("invalid trending config: %w", err)
+	}
+
+	if cfg.Trending != nil && cfg.Dedupe == nil {
+		return App{}, errTrendingRequiresDedupe
+	}
+
+	if fe.TelegramCommands != nil && fe.TelegramCommands.Enabled {
+		if fe.BroadcastType != "TELEGRAM" {
+			return App{}, errCommandsRequireTelegram
 		}
 
-		cfg.Broadcast = telegramClient
+		cfg.Commands = &CommandsConfig{AdminIDs: fe.TelegramCommands.AdminIDs}
 	}
 
 	return cfg, nil
 }
+
+func (fe fileStructureElement) broadcaster() (broadcast.Broadcast, error) {
+	messageTemplate, err := fe.messageTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template: %w", err)
+	}
+
+	if fe.BroadcastType != "TELEGRAM" {
+		stdOutClient := broadcast.NewStdOutClient()
+		stdOutClient.Template = messageTemplate
+
+		return stdOutClient, nil
+	}
+
+	chats := make([]broadcast.TelegramChat, 0, len(fe.TelegramChats)+1)
+
+	if fe.TelegramChatID != "" || len(fe.TelegramChats) == 0 {
+		chats = append(chats, broadcast.TelegramChat{
+			ChatID:            fe.TelegramChatID,
+			DefaultThreadID:   0,
+			ThreadsBySource:   nil,
+			ThreadsByInterest: nil,
+		})
+	}
+
+	for _, chat := range fe.TelegramChats {
+		chats = append(chats, broadcast.TelegramChat{
+			ChatID:            chat.ChatID,
+			DefaultThreadID:   chat.DefaultThreadID,
+			ThreadsBySource:   chat.ThreadsBySource,
+			ThreadsByInterest: chat.ThreadsByInterest,
+		})
+	}
+
+	telegramClient, err := broadcast.NewTelegramClient(broadcast.TelegramConfig{
+		BotAPIToken:        fe.TelegramBotAPIToken,
+		Chats:              chats,
+		Template:           messageTemplate,
+		ParseMode:          fe.TelegramParseMode,
+		DisableLinkPreview: fe.TelegramDisableLinkPreview,
+		SendPhotos:         fe.TelegramSendPhotos,
+		FetchOGImage:       fe.TelegramFetchOGImage,
+		SilentBelowScore:   fe.TelegramSilentBelowScore,
+		ProtectContent:     fe.TelegramProtectContent,
+		FeedbackButtons:    fe.TelegramFeedback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create telegram client: %w", err)
+	}
+
+	return telegramClient, nil
+}
+
+func (fe fileStructureElement) routes(digestEnabled bool) ([]Route, error) {
+	routes := make([]Route, 0, len(fe.Routes))
+
+	for idx, fr := range fe.Routes {
+		route := Route{
+			MinScore:  fr.MinScore,
+			MaxScore:  fr.MaxScore,
+			Digest:    fr.Digest,
+			Broadcast: nil,
+		}
+
+		switch {
+		case fr.MaxScore != 0 && fr.MaxScore <= fr.MinScore:
+			return nil, fmt.Errorf("route %d: %w", idx+1, errInvalidRouteScoreRange)
+		case fr.Digest && !digestEnabled:
+			return nil, fmt.Errorf("route %d: %w", idx+1, errRouteRequiresDigest)
+		case fr.Digest && fr.Broadcast != nil, !fr.Digest && fr.Broadcast == nil:
+			return nil, fmt.Errorf("route %d: %w", idx+1, errRouteTarget)
+		case fr.Broadcast != nil:
+			var err error
+
+			route.Broadcast, err = fr.Broadcast.broadcaster()
+			if err != nil {
+				return nil, fmt.Errorf("route %d: %w", idx+1, err)
+			}
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+// messageTemplate returns the configured message template, nil when the broadcaster default should be used.
+func (fe fileStructureElement) messageTemplate() (*broadcast.Template, error) {
+	templateText := fe.Template
+
+	if fe.TemplateFile != "" {
+		contents, err := os.ReadFile(fe.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading template file: %w", err)
+		}
+
+		templateText = string(contents)
+	}
+
+	if templateText == "" {
+		return nil, nil //nolint:nilnil // template is optional
+	}
+
+	messageTemplate, err := broadcast.NewTemplate(fe.TemplateEngine, templateText)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+
+	return messageTemplate, nil
+}
+
+var (
+	errUnknownDigestGrouping   = errors.New("unknown digest grouping")
+	errCommandsRequireTelegram = errors.New("telegram commands require the TELEGRAM broadcast type")
+	errInvalidRouteScoreRange  = errors.New("maxScore must be above minScore")
+	errRouteRequiresDigest     = errors.New("digest route requires the app digest to be enabled")
+	errRouteTarget             = errors.New("route must either set digest or a broadcast")
+	errUnknownDedupeKeep       = errors.New("unknown dedupe keep mode")
+	errTrendingRequiresDedupe  = errors.New("trending requires dedupe to be enabled")
+	errUnknownIDStrategy       = errors.New("unknown id strategy")
+	errUnknownFilterSet        = errors.New("unknown filter set")
+)
+
+func (fd *fileStructureDigest) toConfig() (*DigestConfig, error) {
+	if fd == nil || !fd.Enabled {
+		return nil, nil //nolint:nilnil // digest is optional
+	}
+
+	location := time.UTC
+
+	if fd.Timezone != "" {
+		var err error
+
+		location, err = time.LoadLocation(fd.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("loading timezone: %w", err)
+		}
+	}
+
+	digestSchedule, err := schedule.Parse(fd.Schedule, location)
+	if err != nil {
+		return nil, fmt.Errorf("parsing schedule: %w", err)
+	}
+
+	digest := DigestConfig{
+		Title:    fd.Title,
+		Schedule: digestSchedule,
+		GroupBy:  fd.GroupBy,
+		TopN:     fd.TopN,
+	}
+
+	if digest.Title == "" {
+		digest.Title = defaultDigestTitle
+	}
+
+	switch digest.GroupBy {
+	case "":
+		digest.GroupBy = DigestGroupBySource
+	case DigestGroupBySource, DigestGroupByReason:
+	default:
+		return nil, fmt.Errorf("%w: '%s'", errUnknownDigestGrouping, digest.GroupBy)
+	}
+
+	return &digest, nil
+}
+
+func (fd *fileStructureDedupe) toConfig() (*DedupeConfig, error) {
+	if fd == nil || !fd.Enabled {
+		return nil, nil //nolint:nilnil // dedupe is optional
+	}
+
+	dedupe := DedupeConfig{
+		Window:              defaultDedupeWindow,
+		TitleSimilarity:     fd.TitleSimilarity,
+		EmbeddingSimilarity: fd.EmbeddingSimilarity,
+		Keep:                fd.Keep,
+		AlsoCoveredBy:       fd.AlsoCoveredBy,
+	}
+
+	if fd.Window != "" {
+		var err error
+
+		dedupe.Window, err = time.ParseDuration(fd.Window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window duration format: %w", err)
+		}
+	}
+
+	if dedupe.TitleSimilarity == 0 {
+		dedupe.TitleSimilarity = defaultDedupeTitleSimilarity
+	}
+
+	switch dedupe.Keep {
+	case "":
+		dedupe.Keep = DedupeKeepFirst
+	case DedupeKeepFirst, DedupeKeepBest:
+	default:
+		return nil, fmt.Errorf("%w: '%s'", errUnknownDedupeKeep, dedupe.Keep)
+	}
+
+	return &dedupe, nil
+}
+
+func (ft *fileStructureTrending) toConfig() (*TrendingConfig, error) {
+	if ft == nil || !ft.Enabled {
+		return nil, nil //nolint:nilnil // trending is optional
+	}
+
+	trending := TrendingConfig{
+		Window:     defaultTrendingWindow,
+		MinSources: ft.MinSources,
+		Broadcast:  nil,
+	}
+
+	if ft.Window != "" {
+		var err error
+
+		trending.Window, err = time.ParseDuration(ft.Window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window duration format: %w", err)
+		}
+	}
+
+	if trending.MinSources <= 0 {
+		trending.MinSources = defaultTrendingMinSources
+	}
+
+	if ft.Broadcast != nil {
+		var err error
+
+		trending.Broadcast, err = ft.Broadcast.broadcaster()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &trending, nil
+}
+
+// idStrategy returns the ID strategy of the source, status pages default to link+published.
+func (fs fileStructureSource) idStrategy() (string, error) {
+	switch fs.IDStrategy {
+	case "":
+		if fs.StatusPage {
+			return IDStrategyLinkPublished, nil
+		}
+
+		return IDStrategyLink, nil
+	case IDStrategyLink, IDStrategyGUID, IDStrategyLinkPublished, IDStrategyTitleLink, IDStrategyContent:
+		return fs.IDStrategy, nil
+	default:
+		return "", fmt.Errorf("%w: '%s'", errUnknownIDStrategy, fs.IDStrategy)
+	}
+}
+
+// parseFilter parses the filter expression, nil when it is empty.
+func parseFilter(expression string) (*filter.Expr, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil //nolint:nilnil // filter is optional
+	}
+
+	expr, err := filter.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", expression, err)
+	}
+
+	return expr, nil
+}
+
+// filterSetsToConfig parses the shared filter sets by their names.
+func filterSetsToConfig(sets map[string]fileStructureFilterSet) (map[string]*FilterSet, error) {
+	filterSets := make(map[string]*FilterSet, len(sets))
+
+	for name, set := range sets {
+		expr, err := parseFilter(set.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter of filter set '%s': %w", name, err)
+		}
+
+		filterSets[name] = &FilterSet{
+			Name:                name,
+			MustIncludeKeywords: set.MustIncludeAnyOf,
+			MustExcludeKeywords: set.MustExcludeAnyOf,
+			Filter:              expr,
+		}
+	}
+
+	return filterSets, nil
+}
+
+// lookupFilterSets returns the filter sets referenced by the names.
+func lookupFilterSets(filterSets map[string]*FilterSet, names []string) ([]*FilterSet, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	sets := make([]*FilterSet, len(names))
+
+	for idx, name := range names {
+		set, ok := filterSets[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s'", errUnknownFilterSet, name)
+		}
+
+		sets[idx] = set
+	}
+
+	return sets, nil
+}
+
+func sourceName(fs fileStructureSource) string {
+	if fs.Name != "" {
+		return fs.Name
+	}
+
+	sourceURL, err := url.Parse(fs.URL)
+	if err != nil || sourceURL.Host == "" {
+		return fs.URL
+	}
+
+	return sourceURL.Host
+}
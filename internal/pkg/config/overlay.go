@@ -0,0 +1,226 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const overlayFilePerm = 0o600
+
+var (
+	errSourceAlreadyExists = errors.New("source already exists")
+	errSourceNotFound      = errors.New("source not found")
+)
+
+// Overlay holds config changes made at runtime (e.g. through bot commands).
+// It is applied on top of the config file and persisted separately, so the config file is never rewritten.
+type Overlay struct {
+	filePath string
+	apps     map[string]*appOverlay
+	mux      *sync.RWMutex
+}
+
+type appOverlay struct {
+	AddedSources   []overlaySource `json:"addedSources,omitempty"`
+	RemovedSources []string        `json:"removedSources,omitempty"`
+	MutedKeywords  []string        `json:"mutedKeywords,omitempty"`
+	PausedUntil    time.Time       `json:"pausedUntil,omitzero"`
+}
+
+type overlaySource struct {
+	URL     string    `json:"url"`
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// newOverlay loads the overlay from the file, starting empty when the file does not exist.
+func newOverlay(filePath string) (*Overlay, error) {
+	overlay := &Overlay{
+		filePath: filePath,
+		apps:     make(map[string]*appOverlay),
+		mux:      &sync.RWMutex{},
+	}
+
+	contents, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return overlay, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading overlay file: %w", err)
+	}
+
+	err = json.Unmarshal(contents, &overlay.apps)
+	if err != nil {
+		return nil, fmt.Errorf("decoding overlay file: %w", err)
+	}
+
+	return overlay, nil
+}
+
+// overlayFilePath places the overlay next to the storage file.
+func overlayFilePath(storageFilePath string) string {
+	return filepath.Join(filepath.Dir(storageFilePath), "overlay.json")
+}
+
+// Sources returns the app sources with runtime additions and removals applied.
+func (o *Overlay) Sources(app string, sources []*Source) []*Source {
+	o.mux.RLock()
+	defer o.mux.RUnlock()
+
+	appChanges := o.apps[app]
+	if appChanges == nil {
+		return sources
+	}
+
+	effective := make([]*Source, 0, len(sources)+len(appChanges.AddedSources))
+
+	for _, source := range sources {
+		if !slices.Contains(appChanges.RemovedSources, source.URL) {
+			effective = append(effective, source)
+		}
+	}
+
+	for _, added := range appChanges.AddedSources {
+		effective = append(effective, &Source{
+			Name:                sourceName(fileStructureSource{URL: added.URL}), //nolint:exhaustruct // only URL matters
+			URL:                 added.URL,
+			IgnoreStoriesBefore: added.AddedAt,
+			MustIncludeKeywords: nil,
+			MustExcludeKeywords: nil,
+			StatusPage:          false,
+			IDStrategy:          IDStrategyLink,
+			Filter:              nil,
+			FilterSets:          nil,
+			Scoring:             nil,
+		})
+	}
+
+	return effective
+}
+
+// AddSource adds the source to the app, stories published before now are ignored.
+func (o *Overlay) AddSource(app string, sources []*Source, sourceURL string) error {
+	if slices.ContainsFunc(o.Sources(app, sources), func(s *Source) bool { return s.URL == sourceURL }) {
+		return errSourceAlreadyExists
+	}
+
+	return o.update(app, func(appChanges *appOverlay) {
+		appChanges.RemovedSources = slices.DeleteFunc(appChanges.RemovedSources, func(u string) bool {
+			return u == sourceURL
+		})
+
+		appChanges.AddedSources = append(appChanges.AddedSources, overlaySource{URL: sourceURL, AddedAt: time.Now().UTC()})
+	})
+}
+
+// RemoveSource removes the source from the app.
+func (o *Overlay) RemoveSource(app string, sources []*Source, sourceURL string) error {
+	if !slices.ContainsFunc(o.Sources(app, sources), func(s *Source) bool { return s.URL == sourceURL }) {
+		return errSourceNotFound
+	}
+
+	return o.update(app, func(appChanges *appOverlay) {
+		appChanges.AddedSources = slices.DeleteFunc(appChanges.AddedSources, func(s overlaySource) bool {
+			return s.URL == sourceURL
+		})
+
+		if slices.ContainsFunc(sources, func(s *Source) bool { return s.URL == sourceURL }) {
+			appChanges.RemovedSources = append(appChanges.RemovedSources, sourceURL)
+		}
+	})
+}
+
+// MutedKeywords returns lowercase keywords which reject stories of the app.
+func (o *Overlay) MutedKeywords(app string) []string {
+	o.mux.RLock()
+	defer o.mux.RUnlock()
+
+	if o.apps[app] == nil {
+		return nil
+	}
+
+	return slices.Clone(o.apps[app].MutedKeywords)
+}
+
+// Mute rejects stories of the app containing the keyword.
+func (o *Overlay) Mute(app, keyword string) error {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+
+	return o.update(app, func(appChanges *appOverlay) {
+		if !slices.Contains(appChanges.MutedKeywords, keyword) {
+			appChanges.MutedKeywords = append(appChanges.MutedKeywords, keyword)
+		}
+	})
+}
+
+// Unmute removes the keyword from the muted ones of the app.
+func (o *Overlay) Unmute(app, keyword string) error {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+
+	return o.update(app, func(appChanges *appOverlay) {
+		appChanges.MutedKeywords = slices.DeleteFunc(appChanges.MutedKeywords, func(k string) bool { return k == keyword })
+	})
+}
+
+// PausedUntil returns until when the app is paused, zero if it is not.
+func (o *Overlay) PausedUntil(app string, now time.Time) time.Time {
+	o.mux.RLock()
+	defer o.mux.RUnlock()
+
+	if o.apps[app] == nil || !o.apps[app].PausedUntil.After(now) {
+		return time.Time{}
+	}
+
+	return o.apps[app].PausedUntil
+}
+
+// Pause stops processing of the app until the given time, zero time resumes it.
+func (o *Overlay) Pause(app string, until time.Time) error {
+	return o.update(app, func(appChanges *appOverlay) {
+		appChanges.PausedUntil = until
+	})
+}
+
+// update applies the change to a copy of the app overlay, which replaces it only once the file is written,
+// so a failed write leaves the overlay in memory as it is on disk.
+func (o *Overlay) update(app string, change func(appChanges *appOverlay)) error {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	changed := appOverlay{AddedSources: nil, RemovedSources: nil, MutedKeywords: nil, PausedUntil: time.Time{}}
+	if current := o.apps[app]; current != nil {
+		changed = appOverlay{
+			AddedSources:   slices.Clone(current.AddedSources),
+			RemovedSources: slices.Clone(current.RemovedSources),
+			MutedKeywords:  slices.Clone(current.MutedKeywords),
+			PausedUntil:    current.PausedUntil,
+		}
+	}
+
+	change(&changed)
+
+	apps := maps.Clone(o.apps)
+	apps[app] = &changed
+
+	contents, err := json.MarshalIndent(apps, "", "\t")
+	if err != nil {
+		return fmt.Errorf("encoding overlay: %w", err)
+	}
+
+	err = os.WriteFile(o.filePath, contents, overlayFilePerm)
+	if err != nil {
+		return fmt.Errorf("writing overlay file: %w", err)
+	}
+
+	o.apps = apps
+
+	return nil
+}
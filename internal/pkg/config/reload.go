@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"mynews/internal/pkg/logger"
+	"slices"
+)
+
+// Reload loads the config file again. The config is fully validated, so a file which fails to load
+// can be reported while the current config keeps running. Storage and the overlay hold runtime state
+// and are kept, moving their files takes a restart.
+func (c *Config) Reload(log *logger.Log) (*Config, error) {
+	file, err := readFile(c.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	reloaded, err := file.toConfig(c.StorageFilePath, log, c)
+	if err != nil {
+		return nil, err
+	}
+
+	reloaded.FilePath = c.FilePath
+
+	return reloaded, nil
+}
+
+// Changes lists apps, sources and broadcasters added or removed by the reloaded config.
+func (c *Config) Changes(reloaded *Config) []string {
+	var changes []string
+
+	apps := make(map[string]App, len(c.Apps))
+	for _, app := range c.Apps {
+		apps[app.Broadcast.Name()] = app
+	}
+
+	for _, app := range reloaded.Apps {
+		appName := app.Broadcast.Name()
+
+		previous, ok := apps[appName]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("added app '%s' with %d sources", appName, len(app.Sources)))
+
+			continue
+		}
+
+		delete(apps, appName)
+
+		changes = append(changes, listChanges(appName, "source", sourceURLs(previous), sourceURLs(app))...)
+		changes = append(changes, listChanges(appName, "broadcaster", broadcasterNames(previous), broadcasterNames(app))...)
+	}
+
+	for _, app := range c.Apps {
+		if _, ok := apps[app.Broadcast.Name()]; ok {
+			changes = append(changes, fmt.Sprintf("removed app '%s'", app.Broadcast.Name()))
+		}
+	}
+
+	return changes
+}
+
+func listChanges(appName, kind string, previous, current []string) []string {
+	var changes []string
+
+	for _, name := range current {
+		if !slices.Contains(previous, name) {
+			changes = append(changes, fmt.Sprintf("added %s '%s' to app '%s'", kind, name, appName))
+		}
+	}
+
+	for _, name := range previous {
+		if !slices.Contains(current, name) {
+			changes = append(changes, fmt.Sprintf("removed %s '%s' from app '%s'", kind, name, appName))
+		}
+	}
+
+	return changes
+}
+
+func sourceURLs(app App) []string {
+	urls := make([]string, len(app.Sources))
+	for idx, source := range app.Sources {
+		urls[idx] = source.URL
+	}
+
+	return urls
+}
+
+func broadcasterNames(app App) []string {
+	var names []string
+
+	for _, broadcaster := range app.Broadcasters()[1:] {
+		names = append(names, broadcaster.Name())
+	}
+
+	return names
+}
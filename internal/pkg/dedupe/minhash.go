@@ -0,0 +1,113 @@
+// Package dedupe recognizes stories telling the same news by the words of their titles.
+package dedupe
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// signatureSize is the number of hash functions of a signature, the similarity estimate is off by about 1/sqrt of it.
+const signatureSize = 64
+
+// Signature is a MinHash of the significant title words, similar titles get similar signatures.
+type Signature []uint64
+
+// NewSignature computes the signature of the title, nil when it has no significant words.
+func NewSignature(title string) Signature {
+	words := titleWords(title)
+	if len(words) == 0 {
+		return nil
+	}
+
+	signature := make(Signature, signatureSize)
+	for idx := range signature {
+		signature[idx] = ^uint64(0)
+	}
+
+	for _, word := range words {
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(word))
+		wordHash := hash.Sum64()
+
+		for idx := range signature {
+			signature[idx] = min(signature[idx], mix(wordHash^seeds[idx]))
+		}
+	}
+
+	return signature
+}
+
+// Similarity estimates the share of significant words the titles have in common (Jaccard index), from 0 to 1.
+func (s Signature) Similarity(other Signature) float64 {
+	if len(s) == 0 || len(s) != len(other) {
+		return 0
+	}
+
+	var equal int
+
+	for idx := range s {
+		if s[idx] == other[idx] {
+			equal++
+		}
+	}
+
+	return float64(equal) / float64(len(s))
+}
+
+// titleWords returns the distinct lowercased title words without stop words and plural endings.
+func titleWords(title string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(fields))
+	words := make([]string, 0, len(fields))
+
+	for _, word := range fields {
+		if stopWords[word] {
+			continue
+		}
+
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = word[:len(word)-1]
+		}
+
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
+
+// mix scrambles the bits of the hash (splitmix64 finalizer), making each seed act as a separate hash function.
+func mix(hash uint64) uint64 {
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+
+	return hash
+}
+
+//nolint:gochecknoglobals // read-only hash seeds
+var seeds = func() [signatureSize]uint64 {
+	var seeds [signatureSize]uint64
+
+	for idx := range seeds {
+		seeds[idx] = mix(uint64(idx) + 0x9e3779b97f4a7c15)
+	}
+
+	return seeds
+}()
+
+//nolint:gochecknoglobals // read-only lookup table
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "says": true, "say": true, "that": true, "the": true, "to": true,
+	"was": true, "were": true, "will": true, "with": true, "after": true, "over": true, "new": true,
+}
@@ -0,0 +1,27 @@
+package dedupe_test
+
+import (
+	"mynews/internal/pkg/dedupe"
+	"testing"
+)
+
+func TestSignatureSimilarity(t *testing.T) {
+	t.Parallel()
+
+	title := dedupe.NewSignature("Apple unveils the iPhone 17 at its September event")
+
+	if similarity := title.Similarity(dedupe.NewSignature("Apple Unveils iPhone 17 at September Event")); similarity != 1 {
+		t.Fatalf("expected titles differing in stop words and case to match, got %g", similarity)
+	}
+
+	similar := title.Similarity(dedupe.NewSignature("Apple unveils iPhone 17 and iPhone Air at September event"))
+	unrelated := title.Similarity(dedupe.NewSignature("Kubernetes 1.40 released with sidecar improvements"))
+
+	if similar < 0.5 || unrelated > 0.2 {
+		t.Fatalf("expected similar titles to score above unrelated ones, got %g and %g", similar, unrelated)
+	}
+
+	if dedupe.NewSignature("the of and").Similarity(dedupe.NewSignature("the of and")) != 0 {
+		t.Fatal("titles without significant words must not match")
+	}
+}
@@ -0,0 +1,228 @@
+// Package filter evaluates filter expressions selecting the stories worth sending, e.g.
+//
+//	title ~ /\bgo(lang)?\b/i && !(category in ["sponsored"]) && score > 0.6
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Story holds the fields filter expressions are evaluated against.
+type Story struct {
+	Title       string
+	Summary     string
+	Content     string
+	Link        string
+	Author      string
+	Source      string
+	Categories  []string
+	PublishedAt time.Time
+	Score       float64 // 0 for stories which are not scored
+	Unscored    bool    // Score conditions are unknown, they neither match nor reject the story
+}
+
+// Expr is a parsed filter expression.
+type Expr struct {
+	expression string
+	root       node
+	usesScore  bool
+}
+
+// SyntaxError describes why an expression does not parse and where.
+type SyntaxError struct {
+	Pos int // byte offset in the expression
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Msg, e.Pos+1)
+}
+
+// Parse parses the expression, errors are *SyntaxError.
+func Parse(expression string) (*Expr, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	exprParser := &parser{tokens: tokens, pos: 0, usesScore: false}
+
+	root, err := exprParser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := exprParser.peek(); next.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: next.pos, Msg: fmt.Sprintf("unexpected %s, expected && or ||", next)}
+	}
+
+	return &Expr{expression: expression, root: root, usesScore: exprParser.usesScore}, nil
+}
+
+// Match reports whether the story satisfies the expression, a nil expression matches every story.
+// Score conditions of unscored stories are unknown, and the story matches unless the rest of the expression
+// rejects it whatever their outcome.
+func (e *Expr) Match(story Story) bool {
+	if e == nil {
+		return true
+	}
+
+	return e.root.match(&story, time.Now()) != no
+}
+
+// UsesScore reports whether the expression refers to the score, so it is evaluated again after scoring.
+func (e *Expr) UsesScore() bool {
+	return e != nil && e.usesScore
+}
+
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.expression
+}
+
+// truth is the outcome of a condition, unknown when it depends on a score the story does not have.
+type truth int
+
+const (
+	no truth = iota
+	yes
+	unknown
+)
+
+func truthOf(value bool) truth {
+	if value {
+		return yes
+	}
+
+	return no
+}
+
+type node interface {
+	match(story *Story, now time.Time) truth
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(story *Story, now time.Time) truth {
+	left, right := n.left.match(story, now), n.right.match(story, now)
+
+	switch {
+	case left == no || right == no:
+		return no
+	case left == yes && right == yes:
+		return yes
+	default:
+		return unknown
+	}
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(story *Story, now time.Time) truth {
+	left, right := n.left.match(story, now), n.right.match(story, now)
+
+	switch {
+	case left == yes || right == yes:
+		return yes
+	case left == no && right == no:
+		return no
+	default:
+		return unknown
+	}
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) match(story *Story, now time.Time) truth {
+	switch n.operand.match(story, now) {
+	case yes:
+		return no
+	case no:
+		return yes
+	default:
+		return unknown
+	}
+}
+
+// textNode matches when any value of the field satisfies the condition.
+type textNode struct {
+	values    func(story *Story) []string
+	condition func(value string) bool
+	negate    bool // the condition must hold for none of the values
+}
+
+func (n textNode) match(story *Story, _ time.Time) truth {
+	for _, value := range n.values(story) {
+		if n.condition(value) {
+			return truthOf(!n.negate)
+		}
+	}
+
+	return truthOf(n.negate)
+}
+
+type numberNode struct {
+	value    func(story *Story, now time.Time) float64
+	operator string
+	operand  float64
+	score    bool // unknown for unscored stories
+}
+
+func (n numberNode) match(story *Story, now time.Time) truth {
+	if n.score && story.Unscored {
+		return unknown
+	}
+
+	value := n.value(story, now)
+
+	switch n.operator {
+	case ">":
+		return truthOf(value > n.operand)
+	case ">=":
+		return truthOf(value >= n.operand)
+	case "<":
+		return truthOf(value < n.operand)
+	case "<=":
+		return truthOf(value <= n.operand)
+	case "==":
+		return truthOf(value == n.operand)
+	default: // "!="
+		return truthOf(value != n.operand)
+	}
+}
+
+//nolint:gochecknoglobals // read-only lookup table
+var textFields = map[string]func(story *Story) []string{
+	"title":    func(story *Story) []string { return []string{story.Title} },
+	"summary":  func(story *Story) []string { return []string{story.Summary} },
+	"content":  func(story *Story) []string { return []string{story.Content} },
+	"text":     func(story *Story) []string { return []string{story.Title, story.Summary, story.Content} },
+	"link":     func(story *Story) []string { return []string{story.Link} },
+	"url":      func(story *Story) []string { return []string{story.Link} },
+	"domain":   func(story *Story) []string { return []string{domain(story.Link)} },
+	"author":   func(story *Story) []string { return []string{story.Author} },
+	"source":   func(story *Story) []string { return []string{story.Source} },
+	"category": func(story *Story) []string { return story.Categories },
+}
+
+//nolint:gochecknoglobals // read-only lookup table
+var numberFields = map[string]func(story *Story, now time.Time) float64{
+	"score": func(story *Story, _ time.Time) float64 { return story.Score },
+	"age":   func(story *Story, now time.Time) float64 { return now.Sub(story.PublishedAt).Seconds() },
+}
+
+// domain returns the host of the link without the "www." prefix.
+func domain(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
@@ -0,0 +1,131 @@
+package filter_test
+
+import (
+	"errors"
+	"mynews/internal/pkg/filter"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	story := filter.Story{
+		Title:       "Golang 1.30 released",
+		Summary:     "The release brings generic methods.",
+		Content:     "",
+		Link:        "https://www.go.dev/blog/go1.30",
+		Author:      "The Go Team",
+		Source:      "go.dev",
+		Categories:  []string{"Programming", "Release"},
+		PublishedAt: time.Now().Add(-3 * time.Hour),
+		Score:       0.7,
+	}
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{expression: `title ~ /\bgo(lang)?\b/i && !(category in ["sponsored"]) && score > 0.6`, expected: true},
+		{expression: `title ~ /\bgo\b/`, expected: false},
+		{expression: `title has "go"`, expected: false},
+		{expression: `text has "generic"`, expected: true},
+		{expression: `title ~ "GOLANG"`, expected: true},
+		{expression: `title !~ "golang" or category == "release"`, expected: true},
+		{expression: `category in ["programming"] and not author ~ "team"`, expected: false},
+		{expression: `domain == "go.dev" && source != "other"`, expected: true},
+		{expression: `age < 2h || age >= 1d`, expected: false},
+		{expression: `age < 3h30m && age > 0.1d`, expected: true},
+		{expression: `score <= 0.5 || (score == 0.7 && link ~ /blog\/go/)`, expected: true},
+	}
+
+	for _, test := range tests {
+		expr, err := filter.Parse(test.expression)
+		if err != nil {
+			t.Fatalf("%s: %v", test.expression, err)
+		}
+
+		if expr.Match(story) != test.expected {
+			t.Errorf("%s: expected %t", test.expression, test.expected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expression string
+		column     int
+	}{
+		{expression: `title ~ "go" &&`, column: 16},
+		{expression: `titel ~ "go"`, column: 1},
+		{expression: `(title ~ "go"`, column: 14},
+		{expression: `score > "high"`, column: 9},
+		{expression: `age > 5`, column: 7},
+		{expression: `title ~ /(go/`, column: 9},
+		{expression: `title == "go" title`, column: 15},
+		{expression: `category in ["a" "b"]`, column: 18},
+	}
+
+	for _, test := range tests {
+		_, err := filter.Parse(test.expression)
+
+		var syntaxErr *filter.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%s: expected a syntax error, got %v", test.expression, err)
+
+			continue
+		}
+
+		if syntaxErr.Pos+1 != test.column {
+			t.Errorf("%s: expected the error at column %d, got %v", test.expression, test.column, err)
+		}
+	}
+
+	expr, err := filter.Parse(`score > 0.5`)
+	if err != nil || !expr.UsesScore() {
+		t.Errorf("expected a valid expression using the score, got %v", err)
+	}
+}
+
+func TestMatchUnscored(t *testing.T) {
+	t.Parallel()
+
+	story := filter.Story{
+		Title:       "Sponsored: Golang webinar",
+		Summary:     "",
+		Content:     "",
+		Link:        "https://example.com/webinar",
+		Author:      "",
+		Source:      "example",
+		Categories:  []string{"Sponsored"},
+		PublishedAt: time.Now(),
+		Score:       0,
+		Unscored:    true,
+	}
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{expression: `score > 0.6`, expected: true},
+		{expression: `!(score > 0.6)`, expected: true},
+		{expression: `title ~ "golang" && score > 0.6`, expected: true},
+		{expression: `title ~ /\bgo(lang)?\b/i && !(category in ["sponsored"]) && score > 0.6`, expected: false},
+		{expression: `title ~ "rust" && score > 0.6`, expected: false},
+		{expression: `title ~ "rust" || score > 0.6`, expected: true},
+		{expression: `source == "example" || score > 0.6`, expected: true},
+	}
+
+	for _, test := range tests {
+		expr, err := filter.Parse(test.expression)
+		if err != nil {
+			t.Fatalf("%s: %v", test.expression, err)
+		}
+
+		if expr.Match(story) != test.expected {
+			t.Errorf("%s: expected %t", test.expression, test.expected)
+		}
+	}
+}
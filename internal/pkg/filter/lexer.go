@@ -0,0 +1,183 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenRegex
+	tokenNumber
+	tokenDuration
+	tokenOperator // comparison and logical operators, parentheses, brackets and commas
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // operator, identifier, unquoted string, regex pattern or number
+	flags string // regex flags
+	pos   int    // byte offset in the expression
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	case tokenRegex:
+		return "/" + t.text + "/" + t.flags
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+//nolint:gochecknoglobals // read-only lookup table, longer operators first
+var operators = []string{"&&", "||", "!~", "==", "!=", ">=", "<=", "~", "!", ">", "<", "(", ")", "[", "]", ","}
+
+// lex splits the expression into tokens, ending with a tokenEOF.
+func lex(expr string) ([]token, error) {
+	var tokens []token
+
+	for pos := 0; pos < len(expr); {
+		char, size := utf8.DecodeRuneInString(expr[pos:])
+
+		switch {
+		case unicode.IsSpace(char):
+			pos += size
+		case char == '"':
+			text, end, err := lexString(expr, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: text, flags: "", pos: pos})
+			pos = end
+		case char == '/':
+			pattern, flags, end, err := lexRegex(expr, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: tokenRegex, text: pattern, flags: flags, pos: pos})
+			pos = end
+		case unicode.IsDigit(char) || char == '.':
+			end := pos
+			for end < len(expr) && (expr[end] >= '0' && expr[end] <= '9' || expr[end] == '.') {
+				end++
+			}
+
+			kind := tokenNumber
+
+			// durations continue with units and further numbers, e.g. 1h30m
+			unitEnd := end
+			for unitEnd < len(expr) && unicode.IsLetter(rune(expr[unitEnd])) {
+				for unitEnd < len(expr) && (unicode.IsLetter(rune(expr[unitEnd])) || unicode.IsDigit(rune(expr[unitEnd])) ||
+					expr[unitEnd] == '.') {
+					unitEnd++
+				}
+			}
+
+			if unitEnd > end {
+				kind = tokenDuration
+			}
+
+			tokens = append(tokens, token{kind: kind, text: expr[pos:unitEnd], flags: "", pos: pos})
+			pos = unitEnd
+		case unicode.IsLetter(char) || char == '_':
+			end := pos
+			for end < len(expr) {
+				next, nextSize := utf8.DecodeRuneInString(expr[end:])
+				if !unicode.IsLetter(next) && !unicode.IsDigit(next) && next != '_' {
+					break
+				}
+
+				end += nextSize
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, text: strings.ToLower(expr[pos:end]), flags: "", pos: pos})
+			pos = end
+		default:
+			operator := lexOperator(expr[pos:])
+			if operator == "" {
+				return nil, &SyntaxError{Pos: pos, Msg: fmt.Sprintf("unexpected character %q", char)}
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, flags: "", pos: pos})
+			pos += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "", flags: "", pos: len(expr)}), nil
+}
+
+func lexOperator(rest string) string {
+	for _, operator := range operators {
+		if strings.HasPrefix(rest, operator) {
+			return operator
+		}
+	}
+
+	return ""
+}
+
+// lexString reads the double quoted string starting at pos, returning its value and the offset after it.
+func lexString(expr string, pos int) (string, int, error) {
+	for end := pos + 1; end < len(expr); end++ {
+		switch expr[end] {
+		case '\\':
+			end++
+		case '"':
+			text, err := strconv.Unquote(expr[pos : end+1])
+			if err != nil {
+				return "", 0, &SyntaxError{Pos: pos, Msg: "invalid escape sequence in string"}
+			}
+
+			return text, end + 1, nil
+		}
+	}
+
+	return "", 0, &SyntaxError{Pos: pos, Msg: "unterminated string"}
+}
+
+// lexRegex reads the /pattern/flags regex starting at pos, returning the offset after it.
+func lexRegex(expr string, pos int) (string, string, int, error) {
+	var pattern strings.Builder
+
+	for end := pos + 1; end < len(expr); end++ {
+		switch {
+		case expr[end] == '\\' && end+1 < len(expr) && expr[end+1] == '/':
+			pattern.WriteByte('/')
+
+			end++
+		case expr[end] == '\\' && end+1 < len(expr):
+			pattern.WriteString(expr[end : end+2])
+
+			end++
+		case expr[end] == '/':
+			flagsEnd := end + 1
+			for flagsEnd < len(expr) && unicode.IsLetter(rune(expr[flagsEnd])) {
+				flagsEnd++
+			}
+
+			flags := expr[end+1 : flagsEnd]
+			if strings.Trim(flags, "ims") != "" {
+				return "", "", 0, &SyntaxError{Pos: end + 1, Msg: fmt.Sprintf("unknown regex flags %q, use i, m or s", flags)}
+			}
+
+			return pattern.String(), flags, flagsEnd, nil
+		default:
+			pattern.WriteByte(expr[end])
+		}
+	}
+
+	return "", "", 0, &SyntaxError{Pos: pos, Msg: "unterminated regex"}
+}
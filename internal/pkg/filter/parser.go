@@ -0,0 +1,300 @@
+package filter
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+type parser struct {
+	tokens    []token
+	pos       int
+	usesScore bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	current := p.tokens[p.pos]
+	if current.kind != tokenEOF {
+		p.pos++
+	}
+
+	return current
+}
+
+// accept consumes the next token when it is one of the operators or keywords.
+func (p *parser) accept(texts ...string) bool {
+	next := p.peek()
+	if (next.kind == tokenOperator || next.kind == tokenIdent) && slices.Contains(texts, next.text) {
+		p.pos++
+
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||", "or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&", "and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!", "not") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{operand: operand}, nil
+	}
+
+	if opening := p.peek(); p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.accept(")") {
+			return nil, &SyntaxError{
+				Pos: p.peek().pos,
+				Msg: fmt.Sprintf("unexpected %s, expected ')' closing the one at column %d", p.peek(), opening.pos+1),
+			}
+		}
+
+		return inner, nil
+	}
+
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (node, error) {
+	field := p.next()
+	if field.kind != tokenIdent {
+		return nil, &SyntaxError{Pos: field.pos, Msg: fmt.Sprintf("unexpected %s, expected a field name", field)}
+	}
+
+	if values, ok := textFields[field.text]; ok {
+		return p.parseTextCondition(values)
+	}
+
+	if value, ok := numberFields[field.text]; ok {
+		p.usesScore = p.usesScore || field.text == "score"
+
+		return p.parseNumberCondition(field.text, value)
+	}
+
+	fields := slices.Sorted(maps.Keys(textFields))
+	fields = append(fields, slices.Sorted(maps.Keys(numberFields))...)
+
+	return nil, &SyntaxError{
+		Pos: field.pos,
+		Msg: fmt.Sprintf("unknown field '%s', expected one of %s", field.text, strings.Join(fields, ", ")),
+	}
+}
+
+func (p *parser) parseTextCondition(values func(story *Story) []string) (node, error) {
+	operator := p.next()
+	result := textNode{values: values, condition: nil, negate: false}
+
+	switch {
+	case operator.kind == tokenOperator && (operator.text == "~" || operator.text == "!~"):
+		condition, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+
+		result.condition, result.negate = condition, operator.text == "!~"
+	case operator.kind == tokenOperator && (operator.text == "==" || operator.text == "!="):
+		value, err := p.expectString()
+		if err != nil {
+			return nil, err
+		}
+
+		result.condition = func(text string) bool { return strings.EqualFold(text, value) }
+		result.negate = operator.text == "!="
+	case operator.kind == tokenIdent && operator.text == "has":
+		word, err := p.expectString()
+		if err != nil {
+			return nil, err
+		}
+
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+		result.condition = pattern.MatchString
+	case operator.kind == tokenIdent && operator.text == "in":
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		result.condition = func(text string) bool {
+			return slices.ContainsFunc(list, func(value string) bool { return strings.EqualFold(text, value) })
+		}
+	default:
+		return nil, &SyntaxError{
+			Pos: operator.pos,
+			Msg: fmt.Sprintf("unexpected %s, expected ~, !~, ==, !=, has or in after a text field", operator),
+		}
+	}
+
+	return result, nil
+}
+
+// parsePattern parses the operand of ~, a regex or a string matched case-insensitively anywhere in the text.
+func (p *parser) parsePattern() (func(text string) bool, error) {
+	operand := p.next()
+
+	switch operand.kind {
+	case tokenRegex:
+		flags := ""
+		if operand.flags != "" {
+			flags = "(?" + operand.flags + ")"
+		}
+
+		pattern, err := regexp.Compile(flags + operand.text)
+		if err != nil {
+			return nil, &SyntaxError{Pos: operand.pos, Msg: fmt.Sprintf("invalid regex: %s", err)}
+		}
+
+		return pattern.MatchString, nil
+	case tokenString:
+		needle := strings.ToLower(operand.text)
+
+		return func(text string) bool { return strings.Contains(strings.ToLower(text), needle) }, nil
+	default:
+		return nil, &SyntaxError{Pos: operand.pos, Msg: fmt.Sprintf("unexpected %s, expected a /regex/ or a string", operand)}
+	}
+}
+
+func (p *parser) expectString() (string, error) {
+	operand := p.next()
+	if operand.kind != tokenString {
+		return "", &SyntaxError{Pos: operand.pos, Msg: fmt.Sprintf("unexpected %s, expected a string", operand)}
+	}
+
+	return operand.text, nil
+}
+
+func (p *parser) parseList() ([]string, error) {
+	opening := p.next()
+	if opening.kind != tokenOperator || opening.text != "[" {
+		return nil, &SyntaxError{Pos: opening.pos, Msg: fmt.Sprintf(`unexpected %s, expected a list like ["a", "b"]`, opening)}
+	}
+
+	var list []string
+
+	for !p.accept("]") {
+		if len(list) != 0 && !p.accept(",") {
+			return nil, &SyntaxError{Pos: p.peek().pos, Msg: fmt.Sprintf("unexpected %s, expected ',' or ']'", p.peek())}
+		}
+
+		value, err := p.expectString()
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, value)
+	}
+
+	return list, nil
+}
+
+func (p *parser) parseNumberCondition(field string, value func(story *Story, now time.Time) float64) (node, error) {
+	operator := p.next()
+	if operator.kind != tokenOperator || !slices.Contains([]string{">", ">=", "<", "<=", "==", "!="}, operator.text) {
+		return nil, &SyntaxError{
+			Pos: operator.pos,
+			Msg: fmt.Sprintf("unexpected %s, expected >, >=, <, <=, == or != after '%s'", operator, field),
+		}
+	}
+
+	operand := p.next()
+	result := numberNode{value: value, operator: operator.text, operand: 0, score: field == "score"}
+
+	switch {
+	case field == "age" && operand.kind == tokenDuration:
+		duration, err := parseDuration(operand.text)
+		if err != nil {
+			return nil, &SyntaxError{Pos: operand.pos, Msg: err.Error()}
+		}
+
+		result.operand = duration.Seconds()
+	case field != "age" && operand.kind == tokenNumber:
+		number, err := strconv.ParseFloat(operand.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{Pos: operand.pos, Msg: fmt.Sprintf("invalid number %s", operand)}
+		}
+
+		result.operand = number
+	case field == "age":
+		return nil, &SyntaxError{Pos: operand.pos, Msg: fmt.Sprintf("unexpected %s, expected a duration like 90m, 2h or 7d", operand)}
+	default:
+		return nil, &SyntaxError{Pos: operand.pos, Msg: fmt.Sprintf("unexpected %s, expected a number", operand)}
+	}
+
+	return result, nil
+}
+
+// parseDuration parses Go durations, and whole or fractional days (d) and weeks (w).
+func parseDuration(text string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": day, "w": week} {
+		if number, ok := strings.CutSuffix(text, suffix); ok {
+			count, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration '%s'", text) //nolint:err113 // wrapped into a SyntaxError
+			}
+
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s', use units like s, m, h, d or w", text) //nolint:err113 // wrapped into a SyntaxError
+	}
+
+	return duration, nil
+}
@@ -8,6 +8,7 @@ import (
 type Level uint
 
 const (
+	Debug Level = iota
 	Info  Level = iota
 	Warn  Level = iota
 	Error Level = iota
@@ -23,6 +24,15 @@ func New(logLevel Level) *Log {
 	}
 }
 
+// SetLevel changes the lowest level of printed messages.
+func (l *Log) SetLevel(logLevel Level) {
+	l.logLevel = logLevel
+}
+
+func (l Log) Debug(msg string) {
+	l.print(Debug, msg)
+}
+
 func (l Log) Info(msg string) {
 	l.print(Info, msg)
 }
@@ -47,6 +57,9 @@ func (l Log) print(logLevel Level, msg ...any) {
 	log.SetOutput(os.Stderr)
 
 	switch logLevel {
+	case Debug:
+		log.SetPrefix("[DEBUG] ")
+		log.SetOutput(os.Stdout)
 	case Info:
 		log.SetPrefix("[INFO] ")
 		log.SetOutput(os.Stdout)
@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"mynews/internal/pkg/timeparser"
+	"strings"
 	"time"
 )
 
@@ -13,13 +14,27 @@ type atomFeed struct {
 }
 
 type atomItem struct {
-	Title   string   `xml:"title"`
-	Updated string   `xml:"updated"`
-	Link    atomLink `xml:"link"`
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Authors    []string       `xml:"author>name"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Thumbnails []mediaContent `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Media      []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	Summary    string         `xml:"summary"`
+	Content    string         `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
 }
 
 type atomLink struct {
 	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
 }
 
 func parseAtom(body []byte) ([]Item, error) {
@@ -35,9 +50,15 @@ func parseAtom(body []byte) ([]Item, error) {
 	for itemIdx := range feed.Items {
 		items[itemIdx] = Item{
 			Title:             feed.Items[itemIdx].Title,
-			Link:              feed.Items[itemIdx].Link.Href,
+			Link:              feed.Items[itemIdx].link(),
+			GUID:              strings.TrimSpace(feed.Items[itemIdx].ID),
+			Author:            strings.Join(feed.Items[itemIdx].Authors, ", "),
 			PublishedAt:       feed.Items[itemIdx].Updated,
 			PublishedAtParsed: time.Time{},
+			ImageURL:          feed.Items[itemIdx].imageURL(),
+			Summary:           plainText(feed.Items[itemIdx].Summary),
+			Content:           excerpt(feed.Items[itemIdx].Content),
+			Categories:        feed.Items[itemIdx].categories(),
 		}
 
 		publishedAtParsed, err := timeparser.ParseUTC(feed.Items[itemIdx].Updated)
@@ -50,3 +71,43 @@ func parseAtom(body []byte) ([]Item, error) {
 
 	return items, nil
 }
+
+// link returns the alternate link of the entry, falling back to the first one.
+func (ai atomItem) link() string {
+	for _, link := range ai.Links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+
+	if len(ai.Links) != 0 {
+		return ai.Links[0].Href
+	}
+
+	return ""
+}
+
+func (ai atomItem) imageURL() string {
+	for _, link := range ai.Links {
+		if link.Rel == "enclosure" && isImageType(link.Type) && link.Href != "" {
+			return link.Href
+		}
+	}
+
+	return mediaImageURL(ai.Thumbnails, ai.Media)
+}
+
+// categories returns labels of the entry categories, falling back to their terms.
+func (ai atomItem) categories() []string {
+	categories := make([]string, 0, len(ai.Categories))
+
+	for _, category := range ai.Categories {
+		if category.Label != "" {
+			categories = append(categories, category.Label)
+		} else if category.Term != "" {
+			categories = append(categories, category.Term)
+		}
+	}
+
+	return categories
+}
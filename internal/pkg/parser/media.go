@@ -0,0 +1,31 @@
+package parser
+
+import "strings"
+
+// mediaContent is a Media RSS (media:thumbnail or media:content) element.
+type mediaContent struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+// mediaImageURL picks the first thumbnail, or the first media content which is an image.
+func mediaImageURL(thumbnails, contents []mediaContent) string {
+	for _, thumbnail := range thumbnails {
+		if thumbnail.URL != "" {
+			return thumbnail.URL
+		}
+	}
+
+	for _, content := range contents {
+		if content.URL != "" && (content.Medium == "image" || isImageType(content.Type)) {
+			return content.URL
+		}
+	}
+
+	return ""
+}
+
+func isImageType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/")
+}
@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var errNoOGImage = errors.New("page has no og:image")
+
+//nolint:gochecknoglobals // patterns are compiled once
+var (
+	metaTagPattern   = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// FetchOGImage returns the og:image URL declared by the page behind the link.
+func FetchOGImage(link string) (string, error) {
+	body, err := fromURL(link)
+	if err != nil {
+		return "", fmt.Errorf("fetching page: %w", err)
+	}
+
+	for _, tag := range metaTagPattern.FindAllString(string(body), -1) {
+		attributes := make(map[string]string)
+
+		for _, match := range attributePattern.FindAllStringSubmatch(tag, -1) {
+			attributes[strings.ToLower(match[1])] = html.UnescapeString(strings.Trim(match[2], `"'`))
+		}
+
+		property := attributes["property"]
+		if property == "" {
+			property = attributes["name"]
+		}
+
+		if strings.EqualFold(property, "og:image") && attributes["content"] != "" {
+			return attributes["content"], nil
+		}
+	}
+
+	return "", errNoOGImage
+}
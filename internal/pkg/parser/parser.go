@@ -7,10 +7,17 @@ import (
 )
 
 type Item struct {
-	Title             string
-	Link              string
-	PublishedAt       string
-	PublishedAtParsed time.Time
+	Title             string    `json:"title"`
+	Link              string    `json:"link"`
+	GUID              string    `json:"guid,omitempty"`   // RSS guid or Atom id, stable across link changes in most feeds
+	Author            string    `json:"author,omitempty"` // RSS author or dc:creator, or Atom author name
+	PublishedAt       string    `json:"publishedAt"`
+	PublishedAtParsed time.Time `json:"publishedAtParsed"`
+	ImageURL          string    `json:"imageURL,omitempty"` // From an image enclosure or Media RSS thumbnail
+
+	Summary    string   `json:"summary,omitempty"`    // Plain text description of the item
+	Content    string   `json:"content,omitempty"`    // Plain text excerpt of the full content
+	Categories []string `json:"categories,omitempty"` // Categories or tags assigned by the feed
 }
 
 var errInvalidFeedType = errors.New("invalid feed type")
@@ -1,9 +1,11 @@
 package parser
 
 import (
+	"cmp"
 	"encoding/xml"
 	"fmt"
 	"mynews/internal/pkg/timeparser"
+	"strings"
 	"time"
 )
 
@@ -15,9 +17,23 @@ type rssFeed struct {
 }
 
 type rssItem struct {
-	Title   string `xml:"title"`
-	Link    string `xml:"link"`
-	PubDate string `xml:"pubDate"`
+	Title      string         `xml:"title"`
+	Link       string         `xml:"link"`
+	GUID       string         `xml:"guid"`
+	Author     string         `xml:"author"`
+	Creator    string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate    string         `xml:"pubDate"`
+	Enclosures []rssEnclosure `xml:"enclosure"`
+	Thumbnails []mediaContent `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Media      []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	Summary    string         `xml:"description"`
+	Content    string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Categories []string       `xml:"category"`
+}
+
+type rssEnclosure struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
 }
 
 func parseRSS(body []byte) ([]Item, error) {
@@ -34,8 +50,14 @@ func parseRSS(body []byte) ([]Item, error) {
 		items[itemIdx] = Item{
 			Title:             feed.Items[itemIdx].Title,
 			Link:              feed.Items[itemIdx].Link,
+			GUID:              strings.TrimSpace(feed.Items[itemIdx].GUID),
+			Author:            strings.TrimSpace(cmp.Or(feed.Items[itemIdx].Creator, feed.Items[itemIdx].Author)),
 			PublishedAt:       feed.Items[itemIdx].PubDate,
 			PublishedAtParsed: time.Time{},
+			ImageURL:          feed.Items[itemIdx].imageURL(),
+			Summary:           plainText(feed.Items[itemIdx].Summary),
+			Content:           excerpt(feed.Items[itemIdx].Content),
+			Categories:        feed.Items[itemIdx].Categories,
 		}
 
 		publishedParsedAt, err := timeparser.ParseUTC(feed.Items[itemIdx].PubDate)
@@ -48,3 +70,13 @@ func parseRSS(body []byte) ([]Item, error) {
 
 	return items, nil
 }
+
+func (ri rssItem) imageURL() string {
+	for _, enclosure := range ri.Enclosures {
+		if isImageType(enclosure.Type) && enclosure.URL != "" {
+			return enclosure.URL
+		}
+	}
+
+	return mediaImageURL(ri.Thumbnails, ri.Media)
+}
@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxContentLength bounds the content excerpt kept for each item, full articles are not needed for scoring.
+const maxContentLength = 4000
+
+//nolint:gochecknoglobals // patterns are compiled once
+var (
+	scriptPattern = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	tagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// plainText strips markup from feed text (summaries and contents are often HTML) and collapses whitespace.
+func plainText(text string) string {
+	text = scriptPattern.ReplaceAllString(text, " ")
+	text = tagPattern.ReplaceAllString(text, " ")
+
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// excerpt returns the plain text cut to maxContentLength characters.
+func excerpt(text string) string {
+	text = plainText(text)
+	if utf8.RuneCountInString(text) <= maxContentLength {
+		return text
+	}
+
+	return string([]rune(text)[:maxContentLength])
+}
@@ -0,0 +1,207 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidExpression = errors.New("invalid schedule expression")
+	errNoNextTime        = errors.New("schedule never fires")
+)
+
+// maxLookahead bounds the search for the next matching minute (covers leap years).
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// Schedule is a cron-like schedule evaluated in a fixed location.
+type Schedule struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek fieldSet
+
+	location *time.Location
+}
+
+type fieldSet map[int]bool
+
+type fieldBounds struct {
+	name     string
+	min, max int
+}
+
+//nolint:gochecknoglobals // exception since constant is impossible
+var (
+	minuteBounds     = fieldBounds{name: "minute", min: 0, max: 59}
+	hourBounds       = fieldBounds{name: "hour", min: 0, max: 23}
+	dayOfMonthBounds = fieldBounds{name: "day of month", min: 1, max: 31}
+	monthBounds      = fieldBounds{name: "month", min: 1, max: 12}
+	dayOfWeekBounds  = fieldBounds{name: "day of week", min: 0, max: 6}
+
+	shorthands = map[string]string{
+		"@hourly":  "0 * * * *",
+		"@daily":   "0 0 * * *",
+		"@weekly":  "0 0 * * 0",
+		"@monthly": "0 0 1 * *",
+	}
+)
+
+// Parse parses a standard five field cron expression ("minute hour day-of-month month day-of-week"),
+// one of @hourly, @daily, @weekly, @monthly, or a plain "HH:MM" time of day.
+// Fields support '*', lists ('1,15'), ranges ('1-5') and steps ('*/15').
+// Unlike classic cron, restricted day-of-month and day-of-week fields must both match.
+func Parse(expression string, location *time.Location) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+
+	if shorthand, ok := shorthands[expression]; ok {
+		expression = shorthand
+	}
+
+	if hour, minute, ok := strings.Cut(expression, ":"); ok {
+		expression = minute + " " + hour + " * * *"
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 { //nolint:mnd // cron expressions have five fields
+		return Schedule{}, fmt.Errorf("%w: expected 5 fields, got %d", errInvalidExpression, len(fields))
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	sched := Schedule{location: location} //nolint:exhaustruct // fields are parsed below
+
+	var err error
+
+	for idx, target := range []struct {
+		set    *fieldSet
+		bounds fieldBounds
+	}{
+		{set: &sched.minutes, bounds: minuteBounds},
+		{set: &sched.hours, bounds: hourBounds},
+		{set: &sched.daysOfMonth, bounds: dayOfMonthBounds},
+		{set: &sched.months, bounds: monthBounds},
+		{set: &sched.daysOfWeek, bounds: dayOfWeekBounds},
+	} {
+		*target.set, err = parseField(fields[idx], target.bounds)
+		if err != nil {
+			return Schedule{}, err
+		}
+	}
+
+	return sched, nil
+}
+
+// Next returns the first time strictly after the given one at which the schedule fires.
+func (s Schedule) Next(after time.Time) (time.Time, error) {
+	candidate := after.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	deadline := candidate.Add(maxLookahead)
+
+	for candidate.Before(deadline) {
+		if !s.months[int(candidate.Month())] || !s.daysOfMonth[candidate.Day()] ||
+			!s.daysOfWeek[int(candidate.Weekday())] {
+			candidate = time.Date(candidate.Year(), candidate.Month(), candidate.Day()+1, 0, 0, 0, 0, s.location)
+
+			continue
+		}
+
+		if !s.hours[candidate.Hour()] {
+			candidate = time.Date(candidate.Year(), candidate.Month(), candidate.Day(), candidate.Hour()+1, 0, 0, 0,
+				s.location)
+
+			continue
+		}
+
+		if !s.minutes[candidate.Minute()] {
+			candidate = candidate.Add(time.Minute)
+
+			continue
+		}
+
+		return candidate, nil
+	}
+
+	return time.Time{}, errNoNextTime
+}
+
+func parseField(field string, bounds fieldBounds) (fieldSet, error) {
+	set := make(fieldSet)
+
+	for part := range strings.SplitSeq(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+
+		if hasStep {
+			var err error
+
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("%w: bad step '%s' in %s field", errInvalidExpression, stepPart, bounds.name)
+			}
+		}
+
+		low, high, err := parseRange(rangePart, bounds)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasStep && !strings.Contains(rangePart, "-") && rangePart != "*" {
+			high = bounds.max
+		}
+
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
+
+func parseRange(rangePart string, bounds fieldBounds) (int, int, error) {
+	if rangePart == "*" {
+		return bounds.min, bounds.max, nil
+	}
+
+	lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+
+	low, err := parseValue(lowPart, bounds)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !isRange {
+		return low, low, nil
+	}
+
+	high, err := parseValue(highPart, bounds)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if high < low {
+		return 0, 0, fmt.Errorf("%w: empty range '%s' in %s field", errInvalidExpression, rangePart, bounds.name)
+	}
+
+	return low, high, nil
+}
+
+func parseValue(valuePart string, bounds fieldBounds) (int, error) {
+	value, err := strconv.Atoi(valuePart)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad value '%s' in %s field", errInvalidExpression, valuePart, bounds.name)
+	}
+
+	// both 0 and 7 mean Sunday
+	if bounds == dayOfWeekBounds && value == 7 {
+		value = 0
+	}
+
+	if value < bounds.min || value > bounds.max {
+		return 0, fmt.Errorf("%w: %s value %d out of range %d-%d",
+			errInvalidExpression, bounds.name, value, bounds.min, bounds.max)
+	}
+
+	return value, nil
+}
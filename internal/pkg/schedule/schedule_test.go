@@ -0,0 +1,85 @@
+package schedule_test
+
+import (
+	"mynews/internal/pkg/schedule"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	vilnius, err := time.LoadLocation("Europe/Vilnius")
+	if err != nil {
+		t.Skip("timezone database is not available")
+	}
+
+	tests := []struct {
+		expression string
+		location   *time.Location
+		after      time.Time
+		expected   time.Time
+	}{
+		{
+			expression: "08:00",
+			location:   vilnius,
+			after:      time.Date(2024, 3, 1, 10, 0, 0, 0, vilnius),
+			expected:   time.Date(2024, 3, 2, 8, 0, 0, 0, vilnius),
+		},
+		{
+			expression: "0 8 * * *",
+			location:   vilnius,
+			after:      time.Date(2024, 3, 1, 7, 59, 30, 0, vilnius),
+			expected:   time.Date(2024, 3, 1, 8, 0, 0, 0, vilnius),
+		},
+		{
+			expression: "*/15 * * * *",
+			location:   time.UTC,
+			after:      time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC),
+			expected:   time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			expression: "30 9 * * 1-5",
+			location:   time.UTC,
+			after:      time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), // Friday
+			expected:   time.Date(2024, 3, 4, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			expression: "@monthly",
+			location:   time.UTC,
+			after:      time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+			expected:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		sched, err := schedule.Parse(test.expression, test.location)
+		if err != nil {
+			t.Errorf("parsing '%s': %v", test.expression, err)
+
+			continue
+		}
+
+		next, err := sched.Next(test.after)
+		if err != nil {
+			t.Errorf("next of '%s': %v", test.expression, err)
+
+			continue
+		}
+
+		if !next.Equal(test.expected) {
+			t.Errorf("next of '%s' after %s: expected %s, got %s", test.expression, test.after, test.expected, next)
+		}
+	}
+}
+
+func TestScheduleParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expression := range []string{"", "* * * *", "61 * * * *", "*/0 * * * *", "5-1 * * * *", "a b c d e"} {
+		_, err := schedule.Parse(expression, time.UTC)
+		if err == nil {
+			t.Errorf("expected error for '%s'", expression)
+		}
+	}
+}
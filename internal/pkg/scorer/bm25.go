@@ -0,0 +1,216 @@
+package scorer
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// bm25K1 controls how quickly repeated terms stop adding to the score.
+	bm25K1 = 1.2
+	// bm25B controls how much longer texts are penalized.
+	bm25B = 0.75
+)
+
+// BM25Scorer scores stories with Okapi BM25, treating each interest as a query.
+// Term rarity comes from a corpus of recently seen stories, so words common
+// in the feeds (e.g. "release") weigh less than rare ones (e.g. "kubernetes").
+type BM25Scorer struct {
+	interests     []Interest
+	antiInterests []Interest
+	terms         map[string][]string // interest -> stemmed terms
+	fieldWeights  *FieldWeights
+	maxTokens     int
+
+	mux             *sync.RWMutex
+	weights         map[string]float64 // interest -> weight learned from reader feedback
+	documentFreq    map[string]int     // term -> number of corpus documents containing it
+	documents       int
+	averageDocument float64 // average number of terms of corpus documents
+}
+
+// NewBM25Scorer creates a BM25 scorer with an empty corpus, see Index.
+func NewBM25Scorer(cfg Config) (*BM25Scorer, error) {
+	if len(cfg.Interests) == 0 {
+		return nil, errNoInterests
+	}
+
+	bm25Scorer := &BM25Scorer{
+		interests:       cfg.Interests,
+		antiInterests:   cfg.AntiInterests,
+		terms:           make(map[string][]string),
+		fieldWeights:    cfg.FieldWeights,
+		maxTokens:       cfg.MaxTokens,
+		mux:             &sync.RWMutex{},
+		weights:         nil,
+		documentFreq:    make(map[string]int),
+		documents:       0,
+		averageDocument: 0,
+	}
+
+	for _, interest := range append(append([]Interest(nil), cfg.Interests...), cfg.AntiInterests...) {
+		bm25Scorer.terms[interest.Text] = tokenize(interest.Text)
+	}
+
+	return bm25Scorer, nil
+}
+
+// Index replaces the corpus term statistics are computed from.
+func (b *BM25Scorer) Index(documents []string) {
+	documentFreq := make(map[string]int)
+	totalTerms := 0
+
+	for _, document := range documents {
+		terms := tokenize(document)
+		totalTerms += len(terms)
+
+		seen := make(map[string]bool, len(terms))
+
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				documentFreq[term]++
+			}
+		}
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.documentFreq = documentFreq
+	b.documents = len(documents)
+	b.averageDocument = 0
+
+	if len(documents) != 0 {
+		b.averageDocument = float64(totalTerms) / float64(len(documents))
+	}
+}
+
+// Score computes BM25 relevance of the story fields to the interests, lowered by relevance to anti-interests.
+func (b *BM25Scorer) Score(_ context.Context, story Story) (Score, error) {
+	fields := story.fields(b.fieldWeights, b.maxTokens)
+
+	fieldTerms := make([][]string, len(fields))
+	for idx, field := range fields {
+		fieldTerms[idx] = tokenize(field.Text)
+	}
+
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	relevance := func(interest Interest) float64 {
+		var score, totalWeight float64
+
+		for idx, field := range fields {
+			score += field.Weight * b.relevance(fieldTerms[idx], b.terms[interest.Text])
+			totalWeight += field.Weight
+		}
+
+		if totalWeight == 0 {
+			return 0
+		}
+
+		return score / totalWeight
+	}
+
+	positive := bestMatch(b.interests, func(idx int) float64 { return relevance(b.interests[idx]) }, b.weights)
+	negative := bestMatch(b.antiInterests, func(idx int) float64 { return relevance(b.antiInterests[idx]) }, nil)
+
+	return Score{
+		Value:    min(max(positive.Similarity-negative.Similarity, 0), 1),
+		Reason:   explain(positive, negative),
+		Interest: positive.Text,
+	}, nil
+}
+
+// ScoreBatch scores the stories one by one, BM25 gains nothing from batching.
+func (b *BM25Scorer) ScoreBatch(ctx context.Context, stories []Story) ([]Score, error) {
+	return scoreEach(ctx, b, stories)
+}
+
+// Learn reweights interests by ratings of the stories they matched.
+func (b *BM25Scorer) Learn(_ context.Context, examples []Example) error {
+	weights := interestWeights(interestTexts(b.interests), examples)
+
+	b.mux.Lock()
+	b.weights = weights
+	b.mux.Unlock()
+
+	return nil
+}
+
+// Name returns the scorer identifier.
+func (b *BM25Scorer) Name() string {
+	return ProviderBM25
+}
+
+// Close is a no-op for BM25 scorer.
+func (b *BM25Scorer) Close() error {
+	return nil
+}
+
+// relevance returns the BM25 score of the document for the query, normalized by the score
+// of a document of average length containing each query term once, and capped at 1.
+func (b *BM25Scorer) relevance(document, query []string) float64 {
+	if len(document) == 0 || len(query) == 0 {
+		return 0
+	}
+
+	termFreq := make(map[string]int, len(document))
+	for _, term := range document {
+		termFreq[term]++
+	}
+
+	averageDocument := b.averageDocument
+	if averageDocument == 0 {
+		averageDocument = float64(len(document))
+	}
+
+	lengthNorm := 1 - bm25B + bm25B*float64(len(document))/averageDocument
+
+	var score, maxScore float64
+
+	for _, term := range query {
+		idf := b.idf(term)
+		maxScore += idf
+
+		if freq := float64(termFreq[term]); freq > 0 {
+			score += idf * freq * (bm25K1 + 1) / (freq + bm25K1*lengthNorm)
+		}
+	}
+
+	if maxScore == 0 {
+		return 0
+	}
+
+	return min(score/maxScore, 1)
+}
+
+// idf is the inverse document frequency of the term, always positive.
+func (b *BM25Scorer) idf(term string) float64 {
+	documentFreq := float64(b.documentFreq[term])
+
+	return math.Log(1 + (float64(b.documents)-documentFreq+0.5)/(documentFreq+0.5)) //nolint:mnd // BM25 smoothing
+}
+
+// tokenize splits the text into lowercase stemmed words, dropping stop words.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+
+		terms = append(terms, stem(word))
+	}
+
+	return terms
+}
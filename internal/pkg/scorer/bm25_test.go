@@ -0,0 +1,64 @@
+package scorer_test
+
+import (
+	"context"
+	"mynews/internal/pkg/scorer"
+	"testing"
+)
+
+func TestBM25Scorer(t *testing.T) {
+	t.Parallel()
+
+	bm25Scorer, err := scorer.NewBM25Scorer(scorer.Config{
+		Provider:      scorer.ProviderBM25,
+		Interests:     scorer.Interests("AI", "kubernetes releases"),
+		AntiInterests: nil,
+		ModelDir:      "",
+		ModelName:     "",
+		FieldWeights:  nil,
+		MaxTokens:     0,
+		Cache:         nil,
+		Workers:       0,
+		LLM:           nil,
+		Composite:     nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bm25Scorer.Index([]string{
+		"Go 1.30 released",
+		"Firefox release notes",
+		"New Linux release",
+		"Kubernetes operators explained",
+		"Rust compiler release",
+	})
+
+	score := func(title string) scorer.Score {
+		t.Helper()
+
+		result, err := bm25Scorer.Score(context.Background(), scorer.Story{
+			Title: title, Summary: "", Content: "", Categories: nil, Source: "",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return result
+	}
+
+	if said := score("He said it rains"); said.Value != 0 {
+		t.Errorf("expected 'ai' not to match inside words, got %v (%s)", said.Value, said.Reason)
+	}
+
+	if ai := score("AI beats humans at Go"); ai.Value < 0.5 || ai.Interest != "AI" {
+		t.Errorf("expected a match of 'AI', got %v (%s)", ai.Value, ai.Reason)
+	}
+
+	// both titles match one of the two terms, the rare one weighs more than the common one
+	rare, common := score("Kubernetes cluster upgraded"), score("Browser released")
+	if rare.Value <= common.Value || rare.Interest != "kubernetes releases" {
+		t.Errorf("expected the rare term to weigh more, got %v (%s) and %v (%s)",
+			rare.Value, rare.Reason, common.Value, common.Reason)
+	}
+}
@@ -0,0 +1,170 @@
+package scorer
+
+import (
+	"cmp"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEmbeddingCacheSize bounds the number of cached vectors, roughly 1.5KB each for MiniLM.
+	DefaultEmbeddingCacheSize = 20000
+
+	// evictionShare is the part of the cache dropped at once, so eviction does not run on every insert.
+	evictionShare = 10
+)
+
+// EmbeddingCache keeps text embeddings on disk, so interests and already seen stories
+// are not encoded again after a restart. The least recently used vectors are evicted first.
+type EmbeddingCache struct {
+	filePath   string
+	maxEntries int
+
+	mux     *sync.Mutex
+	entries map[string]*cachedEmbedding
+	dirty   bool
+}
+
+type cachedEmbedding struct {
+	Vector []float32 // halves the file size, the precision loss does not affect similarities
+	UsedAt int64     // unix nanoseconds of the last use
+}
+
+// NewEmbeddingCache loads the cache from the file, starting empty when the file does not exist.
+func NewEmbeddingCache(filePath string, maxEntries int) (*EmbeddingCache, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultEmbeddingCacheSize
+	}
+
+	cache := &EmbeddingCache{
+		filePath:   filePath,
+		maxEntries: maxEntries,
+		mux:        &sync.Mutex{},
+		entries:    make(map[string]*cachedEmbedding),
+		dirty:      false,
+	}
+
+	cacheFile, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("opening embedding cache: %w", err)
+	}
+
+	defer func() { _ = cacheFile.Close() }()
+
+	err = gob.NewDecoder(cacheFile).Decode(&cache.entries)
+	if err != nil {
+		return nil, fmt.Errorf("decoding embedding cache: %w", err)
+	}
+
+	return cache, nil
+}
+
+// Get returns the cached embedding of the text encoded by the model.
+func (c *EmbeddingCache) Get(model, text string) ([]float64, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	entry, ok := c.entries[cacheKey(model, text)]
+	if !ok {
+		return nil, false
+	}
+
+	// use times alone are not worth rewriting the file, they are saved along with the next new entry
+	entry.UsedAt = time.Now().UnixNano()
+
+	vector := make([]float64, len(entry.Vector))
+	for idx, value := range entry.Vector {
+		vector[idx] = float64(value)
+	}
+
+	return vector, true
+}
+
+// Put caches the embedding of the text encoded by the model.
+func (c *EmbeddingCache) Put(model, text string, vector []float64) {
+	entry := &cachedEmbedding{Vector: make([]float32, len(vector)), UsedAt: time.Now().UnixNano()}
+	for idx, value := range vector {
+		entry.Vector[idx] = float32(value)
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.entries[cacheKey(model, text)] = entry
+	c.dirty = true
+
+	if len(c.entries) > c.maxEntries {
+		c.evict()
+	}
+}
+
+// Save writes the cache to its file if it changed since the last save.
+func (c *EmbeddingCache) Save() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	// write to a temporary file first, so an interrupted save does not corrupt the cache
+	tmpFile, err := os.CreateTemp(filepath.Dir(c.filePath), filepath.Base(c.filePath)+".*")
+	if err != nil {
+		return fmt.Errorf("creating embedding cache file: %w", err)
+	}
+
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	err = gob.NewEncoder(tmpFile).Encode(c.entries)
+	if err != nil {
+		_ = tmpFile.Close()
+
+		return fmt.Errorf("encoding embedding cache: %w", err)
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("writing embedding cache: %w", err)
+	}
+
+	err = os.Rename(tmpFile.Name(), c.filePath)
+	if err != nil {
+		return fmt.Errorf("replacing embedding cache: %w", err)
+	}
+
+	c.dirty = false
+
+	return nil
+}
+
+// evict drops the least recently used entries, leaving room for further inserts.
+func (c *EmbeddingCache) evict() {
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Compare(c.entries[a].UsedAt, c.entries[b].UsedAt)
+	})
+
+	keep := c.maxEntries - c.maxEntries/evictionShare
+
+	for _, key := range keys[:len(keys)-keep] {
+		delete(c.entries, key)
+	}
+}
+
+func cacheKey(model, text string) string {
+	return model + ":" + hashText(text)
+}
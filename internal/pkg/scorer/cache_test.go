@@ -0,0 +1,91 @@
+package scorer_test
+
+import (
+	"mynews/internal/pkg/scorer"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestEmbeddingCacheSurvivesSave(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "embeddings.cache")
+
+	cache, err := scorer.NewEmbeddingCache(filePath, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for idx := range 11 {
+		cache.Put("model", "text "+strconv.Itoa(idx), []float64{float64(idx), 0.5})
+	}
+
+	err = cache.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := scorer.NewEmbeddingCache(filePath, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vector, ok := reloaded.Get("model", "text 10")
+	if !ok || len(vector) != 2 || vector[0] != 10 || vector[1] != 0.5 {
+		t.Fatalf("expected the last vector to be cached, got %v", vector)
+	}
+
+	if _, ok = reloaded.Get("other model", "text 10"); ok {
+		t.Fatal("expected vectors of other models to be missing")
+	}
+
+	cached := 0
+
+	for idx := range 11 {
+		if _, ok = reloaded.Get("model", "text "+strconv.Itoa(idx)); ok {
+			cached++
+		}
+	}
+
+	if cached > 10 {
+		t.Fatalf("expected the cache to be bounded, got %d entries", cached)
+	}
+}
+
+func TestEmbeddingCacheSkipsSavingAfterReadsOnly(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "embeddings.cache")
+
+	cache, err := scorer.NewEmbeddingCache(filePath, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Put("model", "text", []float64{1, 0.5})
+
+	err = cache.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Remove(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cache.Get("model", "text"); !ok {
+		t.Fatal("expected the vector to be cached")
+	}
+
+	err = cache.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(filePath); !os.IsNotExist(err) {
+		t.Error("expected the cache not to be saved after reads only")
+	}
+}
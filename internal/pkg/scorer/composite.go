@@ -0,0 +1,219 @@
+package scorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	// CompositeMax scores stories by the member scorer rating them the highest.
+	CompositeMax = "max"
+	// CompositeWeighted scores stories by the weighted average of the member scores.
+	CompositeWeighted = "weighted"
+	// CompositeGate scores stories by the first member only, until they reach the gate score,
+	// and by the weighted average of the remaining members afterwards.
+	CompositeGate = "gate"
+)
+
+var (
+	errCompositeNoMembers   = errors.New("composite provider requires member scorers")
+	errCompositeNested      = errors.New("composite provider cannot contain another composite one")
+	errCompositeGateMembers = errors.New("gate strategy requires a gate and at least one more scorer")
+	errCompositeStrategy    = errors.New("unknown composite strategy")
+)
+
+// CompositeConfig configures a scorer combining several providers.
+type CompositeConfig struct {
+	Strategy  string // CompositeMax, CompositeWeighted or CompositeGate, empty for weighted
+	Members   []CompositeMember
+	GateScore float64 // Score of the first member letting a story through, for the gate strategy
+}
+
+// CompositeMember is a provider combined by the composite scorer, sharing the rest of the scorer config.
+type CompositeMember struct {
+	Provider string
+	Weight   float64 // Weight in the average, 0 means 1
+}
+
+func (m CompositeMember) weight() float64 {
+	if m.Weight == 0 {
+		return 1
+	}
+
+	return m.Weight
+}
+
+// CompositeScorer combines scores of several scorers, e.g. a cheap keyword gate in front of embeddings.
+type CompositeScorer struct {
+	cfg     CompositeConfig
+	members []Scorer // in the order of cfg.Members
+}
+
+// NewCompositeScorer combines the member scorers, given in the order of the config members.
+func NewCompositeScorer(cfg CompositeConfig, members []Scorer) (*CompositeScorer, error) {
+	if len(members) == 0 || len(members) != len(cfg.Members) {
+		return nil, errCompositeNoMembers
+	}
+
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = CompositeWeighted
+	case CompositeMax, CompositeWeighted:
+	case CompositeGate:
+		if len(members) < 2 { //nolint:mnd // the gate and a scorer behind it
+			return nil, errCompositeGateMembers
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", errCompositeStrategy, cfg.Strategy)
+	}
+
+	return &CompositeScorer{
+		cfg:     cfg,
+		members: members,
+	}, nil
+}
+
+// Score combines member scores of the story.
+func (c *CompositeScorer) Score(ctx context.Context, story Story) (Score, error) {
+	scores, err := c.ScoreBatch(ctx, []Story{story})
+	if err != nil {
+		return Score{}, err
+	}
+
+	return scores[0], nil
+}
+
+// ScoreBatch combines member scores of the stories, gated stories are not scored by the remaining members.
+func (c *CompositeScorer) ScoreBatch(ctx context.Context, stories []Story) ([]Score, error) {
+	if c.cfg.Strategy != CompositeGate {
+		return c.combine(ctx, c.cfg.Members, c.members, stories)
+	}
+
+	gateScores, err := c.members[0].ScoreBatch(ctx, stories)
+	if err != nil {
+		return nil, fmt.Errorf("%s gate: %w", c.members[0].Name(), err)
+	}
+
+	var (
+		passed  []Story
+		indexes []int // of passed stories
+	)
+
+	for idx, score := range gateScores {
+		if score.Value >= c.cfg.GateScore {
+			passed = append(passed, stories[idx])
+			indexes = append(indexes, idx)
+		}
+	}
+
+	// stories stopped by the gate keep its reason, with no value
+	scores := make([]Score, len(stories))
+	for idx, score := range gateScores {
+		scores[idx] = Score{Value: 0, Reason: score.Reason, Interest: ""}
+	}
+
+	if len(passed) == 0 {
+		return scores, nil
+	}
+
+	passedScores, err := c.combine(ctx, c.cfg.Members[1:], c.members[1:], passed)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, score := range passedScores {
+		scores[indexes[idx]] = score
+	}
+
+	return scores, nil
+}
+
+// combine scores the stories by each member and merges the scores per story according to the strategy.
+func (c *CompositeScorer) combine(
+	ctx context.Context, members []CompositeMember, scorers []Scorer, stories []Story,
+) ([]Score, error) {
+	memberScores := make([][]Score, len(scorers))
+
+	for idx, memberScorer := range scorers {
+		scores, err := memberScorer.ScoreBatch(ctx, stories)
+		if err != nil {
+			return nil, fmt.Errorf("%s scorer: %w", memberScorer.Name(), err)
+		}
+
+		memberScores[idx] = scores
+	}
+
+	scores := make([]Score, len(stories))
+
+	for storyIdx := range stories {
+		var (
+			best        Score
+			bestValue   = -1.0 // of the member providing the reason
+			total, sumW float64
+		)
+
+		for memberIdx, member := range members {
+			score := memberScores[memberIdx][storyIdx]
+
+			value := score.Value
+			if c.cfg.Strategy != CompositeMax {
+				value *= member.weight()
+			}
+
+			if value > bestValue {
+				best, bestValue = score, value
+			}
+
+			total += score.Value * member.weight()
+			sumW += member.weight()
+		}
+
+		if c.cfg.Strategy != CompositeMax && sumW > 0 {
+			best.Value = total / sumW
+		}
+
+		scores[storyIdx] = best
+	}
+
+	return scores, nil
+}
+
+// Learn passes the feedback to members which learn from it.
+func (c *CompositeScorer) Learn(ctx context.Context, examples []Example) error {
+	for _, member := range c.members {
+		if learner, ok := member.(Learner); ok {
+			err := learner.Learn(ctx, examples)
+			if err != nil {
+				return fmt.Errorf("%s scorer: %w", member.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Index passes the corpus to members which index it.
+func (c *CompositeScorer) Index(documents []string) {
+	for _, member := range c.members {
+		if indexer, ok := member.(Indexer); ok {
+			indexer.Index(documents)
+		}
+	}
+}
+
+// Name returns the scorer identifier.
+func (c *CompositeScorer) Name() string {
+	return ProviderComposite
+}
+
+// Close releases all member scorers.
+func (c *CompositeScorer) Close() error {
+	var errs []error
+
+	for _, member := range c.members {
+		errs = append(errs, member.Close())
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,114 @@
+package scorer_test
+
+import (
+	"context"
+	"math"
+	"mynews/internal/pkg/scorer"
+	"testing"
+)
+
+// fixedScorer scores stories by their title, remembering the titles it scored.
+type fixedScorer struct {
+	values map[string]float64
+	scored []string
+}
+
+func (f *fixedScorer) Score(_ context.Context, story scorer.Story) (scorer.Score, error) {
+	f.scored = append(f.scored, story.Title)
+
+	return scorer.Score{Value: f.values[story.Title], Reason: "fixed", Interest: ""}, nil
+}
+
+func (f *fixedScorer) ScoreBatch(ctx context.Context, stories []scorer.Story) ([]scorer.Score, error) {
+	scores := make([]scorer.Score, len(stories))
+
+	for idx, story := range stories {
+		scores[idx], _ = f.Score(ctx, story)
+	}
+
+	return scores, nil
+}
+
+func (f *fixedScorer) Name() string { return "fixed" }
+
+func (f *fixedScorer) Close() error { return nil }
+
+func compositeScorer(t *testing.T, strategy string, members ...scorer.Scorer) *scorer.CompositeScorer {
+	t.Helper()
+
+	compositeMembers := make([]scorer.CompositeMember, len(members))
+	for idx, member := range members {
+		compositeMembers[idx] = scorer.CompositeMember{Provider: member.Name(), Weight: float64(idx + 1)}
+	}
+
+	composite, err := scorer.NewCompositeScorer(scorer.CompositeConfig{
+		Strategy:  strategy,
+		Members:   compositeMembers,
+		GateScore: 0.1,
+	}, members)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return composite
+}
+
+var compositeStories = []scorer.Story{ //nolint:gochecknoglobals // shared test input
+	{Title: "a", Summary: "", Content: "", Categories: nil, Source: ""},
+	{Title: "b", Summary: "", Content: "", Categories: nil, Source: ""},
+}
+
+func TestCompositeScorer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		strategy string
+		expected []float64
+	}{
+		{strategy: scorer.CompositeMax, expected: []float64{0.8, 0.6}},
+		{strategy: scorer.CompositeWeighted, expected: []float64{0.6, 0.4}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.strategy, func(t *testing.T) {
+			t.Parallel()
+
+			composite := compositeScorer(t, test.strategy,
+				&fixedScorer{values: map[string]float64{"a": 0.2, "b": 0.6}, scored: nil},
+				&fixedScorer{values: map[string]float64{"a": 0.8, "b": 0.3}, scored: nil},
+			)
+
+			scores, err := composite.ScoreBatch(context.Background(), compositeStories)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for idx, score := range scores {
+				if math.Abs(score.Value-test.expected[idx]) > 1e-9 {
+					t.Fatalf("story %d: expected %g, got %g", idx, test.expected[idx], score.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestCompositeScorerGate(t *testing.T) {
+	t.Parallel()
+
+	behind := &fixedScorer{values: map[string]float64{"a": 0.8, "b": 0.9}, scored: nil}
+	composite := compositeScorer(t, scorer.CompositeGate,
+		&fixedScorer{values: map[string]float64{"a": 0.5, "b": 0}, scored: nil}, behind)
+
+	scores, err := composite.ScoreBatch(context.Background(), compositeStories)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scores[0].Value != 0.8 || scores[1].Value != 0 {
+		t.Fatalf("unexpected scores %+v", scores)
+	}
+
+	if len(behind.scored) != 1 || behind.scored[0] != "a" {
+		t.Fatalf("gated stories must not be scored further, scored %v", behind.scored)
+	}
+}
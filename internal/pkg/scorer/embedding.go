@@ -1,12 +1,15 @@
 package scorer
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 
 	"github.com/nlpodyssey/cybertron/pkg/models/bert"
 	"github.com/nlpodyssey/cybertron/pkg/tasks"
@@ -19,15 +22,36 @@ const (
 
 	defaultModelDirPerm = 0o755
 	scoreNormalizer     = 2
+
+	// exampleInfluence scales how much similarity to rated stories moves the score.
+	exampleInfluence = 0.5
 )
 
-var errNoInterests = errors.New("at least one interest is required")
+var (
+	errNoInterests = errors.New("at least one interest is required")
+	errEmptyStory  = errors.New("story has no text to score")
+)
 
 // EmbeddingScorer scores stories using semantic similarity with sentence embeddings.
 type EmbeddingScorer struct {
-	model              textencoding.Interface
-	interestTexts      []string
-	interestEmbeddings [][]float64
+	model                  textencoding.Interface
+	modelName              string
+	cache                  *EmbeddingCache
+	interests              []Interest
+	interestEmbeddings     [][]float64
+	antiInterests          []Interest
+	antiInterestEmbeddings [][]float64
+	fieldWeights           *FieldWeights
+	maxTokens              int
+	workers                int
+	release                func() // returns the model to the pool it came from, nil when loaded on its own
+
+	// learned from reader feedback, guarded by mux
+	mux                *sync.RWMutex
+	interestWeights    map[string]float64
+	likedEmbeddings    [][]float64
+	dislikedEmbeddings [][]float64
+	exampleEmbeddings  map[string][]float64 // example text -> embedding, avoids re-encoding on every Learn
 }
 
 // NewEmbeddingScorer creates a new embedding-based scorer.
@@ -36,6 +60,16 @@ func NewEmbeddingScorer(cfg Config) (*EmbeddingScorer, error) {
 		return nil, errNoInterests
 	}
 
+	model, err := loadModel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEmbeddingScorer(cfg, model)
+}
+
+// loadModel loads the sentence embedding model of the config, downloading it when missing.
+func loadModel(cfg Config) (textencoding.Interface, error) {
 	modelName := cfg.ModelName
 	if modelName == "" {
 		modelName = DefaultModelName
@@ -70,49 +104,236 @@ func NewEmbeddingScorer(cfg Config) (*EmbeddingScorer, error) {
 		return nil, fmt.Errorf("failed to load model %s: %w", modelName, err)
 	}
 
+	return model, nil
+}
+
+// newEmbeddingScorer creates an embedding-based scorer using an already loaded model.
+func newEmbeddingScorer(cfg Config, model textencoding.Interface) (*EmbeddingScorer, error) {
+	if len(cfg.Interests) == 0 {
+		return nil, errNoInterests
+	}
+
 	embeddingScorer := &EmbeddingScorer{
-		model:              model,
-		interestTexts:      cfg.Interests,
-		interestEmbeddings: make([][]float64, len(cfg.Interests)),
+		model:                  model,
+		modelName:              cmp.Or(cfg.ModelName, DefaultModelName),
+		cache:                  cfg.Cache,
+		interests:              cfg.Interests,
+		interestEmbeddings:     nil,
+		antiInterests:          cfg.AntiInterests,
+		antiInterestEmbeddings: nil,
+		fieldWeights:           cfg.FieldWeights,
+		maxTokens:              cfg.MaxTokens,
+		workers:                cfg.Workers,
+		release:                nil,
+		mux:                    &sync.RWMutex{},
+		interestWeights:        nil,
+		likedEmbeddings:        nil,
+		dislikedEmbeddings:     nil,
+		exampleEmbeddings:      make(map[string][]float64),
+	}
+
+	if embeddingScorer.workers <= 0 {
+		embeddingScorer.workers = runtime.NumCPU()
 	}
 
 	// Pre-compute embeddings for all interests
-	ctx := context.Background()
+	var err error
 
-	for interestIdx, interest := range cfg.Interests {
-		result, encodeErr := model.Encode(ctx, interest, int(bert.MeanPooling))
-		if encodeErr != nil {
-			return nil, fmt.Errorf("failed to encode interest %q: %w", interest, encodeErr)
-		}
+	embeddingScorer.interestEmbeddings, err = embeddingScorer.encodeInterests(cfg.Interests)
+	if err != nil {
+		return nil, err
+	}
 
-		embeddingScorer.interestEmbeddings[interestIdx] = result.Vector.Data().F64()
+	embeddingScorer.antiInterestEmbeddings, err = embeddingScorer.encodeInterests(cfg.AntiInterests)
+	if err != nil {
+		return nil, err
 	}
 
 	return embeddingScorer, nil
 }
 
-// Score computes semantic similarity between the story title and user interests.
-func (e *EmbeddingScorer) Score(ctx context.Context, title string) (Score, error) {
-	// Encode the story title
-	result, err := e.model.Encode(ctx, title, int(bert.MeanPooling))
+func (e *EmbeddingScorer) encodeInterests(interests []Interest) ([][]float64, error) {
+	embeddings := make([][]float64, len(interests))
+
+	for interestIdx, interest := range interests {
+		embedding, err := e.encode(context.Background(), interest.Text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode interest %q: %w", interest.Text, err)
+		}
+
+		embeddings[interestIdx] = embedding
+	}
+
+	return embeddings, nil
+}
+
+// encode returns the embedding of the text, using the cache when there is one.
+func (e *EmbeddingScorer) encode(ctx context.Context, text string) ([]float64, error) {
+	if e.cache != nil {
+		if embedding, ok := e.cache.Get(e.modelName, text); ok {
+			return embedding, nil
+		}
+	}
+
+	result, err := e.model.Encode(ctx, text, int(bert.MeanPooling))
 	if err != nil {
-		return Score{}, fmt.Errorf("failed to encode title: %w", err)
+		return nil, fmt.Errorf("encoding text: %w", err)
 	}
 
-	titleEmbedding := result.Vector.Data().F64()
+	embedding := result.Vector.Data().F64()
 
-	// Find the highest similarity to any interest
-	maxSim := 0.0
-	bestMatch := ""
+	if e.cache != nil {
+		e.cache.Put(e.modelName, text, embedding)
+	}
+
+	return embedding, nil
+}
+
+// Score computes semantic similarity between the story and user interests.
+func (e *EmbeddingScorer) Score(ctx context.Context, story Story) (Score, error) {
+	scores, err := e.ScoreBatch(ctx, []Story{story})
+	if err != nil {
+		return Score{}, err
+	}
+
+	return scores[0], nil
+}
+
+// ScoreBatch scores the stories together, encoding their distinct texts in parallel.
+func (e *EmbeddingScorer) ScoreBatch(ctx context.Context, stories []Story) ([]Score, error) {
+	var texts []string
+
+	for _, story := range stories {
+		for _, field := range story.fields(e.fieldWeights, e.maxTokens) {
+			texts = append(texts, field.Text)
+		}
+	}
+
+	embeddings, err := e.encodeAll(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode stories: %w", err)
+	}
+
+	scores := make([]Score, len(stories))
+
+	for idx, story := range stories {
+		storyEmbedding, err := e.storyEmbedding(story, embeddings)
+		if err != nil {
+			return nil, err
+		}
+
+		scores[idx] = e.scoreEmbedding(storyEmbedding)
+	}
+
+	return scores, nil
+}
+
+// encodeAll encodes the distinct texts with a bounded number of concurrent encoders.
+func (e *EmbeddingScorer) encodeAll(ctx context.Context, texts []string) (map[string][]float64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mux        sync.Mutex
+		wg         sync.WaitGroup
+		firstErr   error
+		embeddings = make(map[string][]float64, len(texts))
+		queue      = make(chan string)
+	)
+
+	for range e.workers {
+		wg.Go(func() {
+			for text := range queue {
+				embedding, err := e.encode(ctx, text)
+
+				mux.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+
+					cancel()
+				}
+
+				embeddings[text] = embedding
+				mux.Unlock()
+			}
+		})
+	}
+
+	seen := make(map[string]bool, len(texts))
+
+	for _, text := range texts {
+		if seen[text] {
+			continue
+		}
+
+		seen[text] = true
+
+		select {
+		case queue <- text:
+		case <-ctx.Done():
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
 
-	for i, interestEmb := range e.interestEmbeddings {
-		sim := cosineSimilarity(titleEmbedding, interestEmb)
-		if sim > maxSim {
-			maxSim = sim
-			bestMatch = e.interestTexts[i]
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("encoding stories: %w", ctx.Err())
+	}
+
+	return embeddings, nil
+}
+
+// Embed encodes the texts into unit-length embeddings.
+func (e *EmbeddingScorer) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, err := e.encodeAll(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := make([][]float64, len(texts))
+
+	for idx, text := range texts {
+		embedding := embeddings[text]
+		unit[idx] = make([]float64, len(embedding))
+
+		norm := math.Sqrt(dot(embedding, embedding))
+		if norm == 0 {
+			continue
+		}
+
+		for valueIdx, value := range embedding {
+			unit[idx][valueIdx] = value / norm
 		}
 	}
 
+	return unit, nil
+}
+
+// scoreEmbedding computes the score of a story embedding.
+func (e *EmbeddingScorer) scoreEmbedding(storyEmbedding []float64) Score {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+
+	// Find the highest similarity to any interest, weighted by reader feedback,
+	// and lower it by the highest similarity to any anti-interest
+	positive := bestMatch(e.interests, func(idx int) float64 {
+		return cosineSimilarity(storyEmbedding, e.interestEmbeddings[idx])
+	}, e.interestWeights)
+	negative := bestMatch(e.antiInterests, func(idx int) float64 {
+		return cosineSimilarity(storyEmbedding, e.antiInterestEmbeddings[idx])
+	}, nil)
+
+	maxSim := positive.Similarity - negative.Similarity
+
+	// Stories resembling liked ones are pushed up, ones resembling disliked ones down
+	maxSim += exampleInfluence * (max(maxSimilarity(storyEmbedding, e.likedEmbeddings), 0) -
+		max(maxSimilarity(storyEmbedding, e.dislikedEmbeddings), 0))
+
 	// Normalize similarity to 0-1 range (cosine similarity can be negative)
 	// For sentence-transformers, values typically range from -1 to 1
 	normalizedScore := (maxSim + 1) / scoreNormalizer
@@ -126,9 +347,87 @@ func (e *EmbeddingScorer) Score(ctx context.Context, title string) (Score, error
 	}
 
 	return Score{
-		Value:  normalizedScore,
-		Reason: bestMatch,
-	}, nil
+		Value:    normalizedScore,
+		Reason:   explain(positive, negative),
+		Interest: positive.Text,
+	}
+}
+
+// storyEmbedding averages unit embeddings of the weighted story fields by their weights.
+func (e *EmbeddingScorer) storyEmbedding(story Story, embeddings map[string][]float64) ([]float64, error) {
+	var (
+		embedding   []float64
+		totalWeight float64
+	)
+
+	for _, field := range story.fields(e.fieldWeights, e.maxTokens) {
+		fieldEmbedding := embeddings[field.Text]
+
+		if embedding == nil {
+			embedding = make([]float64, len(fieldEmbedding))
+		}
+
+		norm := math.Sqrt(dot(fieldEmbedding, fieldEmbedding))
+		if norm == 0 || len(fieldEmbedding) != len(embedding) {
+			continue
+		}
+
+		for idx, value := range fieldEmbedding {
+			embedding[idx] += field.Weight * value / norm
+		}
+
+		totalWeight += field.Weight
+	}
+
+	if totalWeight == 0 {
+		return nil, errEmptyStory
+	}
+
+	for idx := range embedding {
+		embedding[idx] /= totalWeight
+	}
+
+	return embedding, nil
+}
+
+// Learn reweights interests by ratings of the stories they matched and remembers
+// rated stories, so similar ones score higher (liked) or lower (disliked).
+func (e *EmbeddingScorer) Learn(ctx context.Context, examples []Example) error {
+	var liked, disliked [][]float64
+
+	for _, example := range examples {
+		e.mux.RLock()
+		embedding, ok := e.exampleEmbeddings[example.Text]
+		e.mux.RUnlock()
+
+		if !ok {
+			var err error
+
+			embedding, err = e.encode(ctx, example.Text)
+			if err != nil {
+				return fmt.Errorf("failed to encode example %q: %w", example.Text, err)
+			}
+
+			e.mux.Lock()
+			e.exampleEmbeddings[example.Text] = embedding
+			e.mux.Unlock()
+		}
+
+		if example.Positive {
+			liked = append(liked, embedding)
+		} else {
+			disliked = append(disliked, embedding)
+		}
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.interestWeights = interestWeights(interestTexts(e.interests), examples)
+	e.likedEmbeddings = liked
+	e.dislikedEmbeddings = disliked
+
+	return nil
 }
 
 // Name returns the scorer identifier.
@@ -138,26 +437,45 @@ func (e *EmbeddingScorer) Name() string {
 
 // Close releases model resources.
 func (e *EmbeddingScorer) Close() error {
+	if e.release != nil {
+		e.release()
+		e.release = nil
+	}
+
 	return nil
 }
 
+// maxSimilarity returns the highest cosine similarity of the vector to any of the others, 0 when there are none.
+func maxSimilarity(vec []float64, others [][]float64) float64 {
+	maxSim := 0.0
+
+	for _, other := range others {
+		maxSim = max(maxSim, cosineSimilarity(vec, other))
+	}
+
+	return maxSim
+}
+
 // cosineSimilarity computes the cosine similarity between two vectors.
 func cosineSimilarity(vecA, vecB []float64) float64 {
 	if len(vecA) != len(vecB) || len(vecA) == 0 {
 		return 0
 	}
 
-	var dotProduct, normA, normB float64
-
-	for i := range vecA {
-		dotProduct += vecA[i] * vecB[i]
-		normA += vecA[i] * vecA[i]
-		normB += vecB[i] * vecB[i]
-	}
-
+	normA, normB := dot(vecA, vecA), dot(vecB, vecB)
 	if normA == 0 || normB == 0 {
 		return 0
 	}
 
-	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+	return dot(vecA, vecB) / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func dot(vecA, vecB []float64) float64 {
+	var product float64
+
+	for i := range vecA {
+		product += vecA[i] * vecB[i]
+	}
+
+	return product
 }
@@ -0,0 +1,64 @@
+package scorer
+
+import "context"
+
+// Example is a story rated by a reader, used to adjust future scores.
+type Example struct {
+	Text     string
+	Interest string // Best matching interest of the story when it was rated
+	Positive bool
+}
+
+// Indexer is implemented by scorers which weigh terms by their frequency in recently seen stories.
+type Indexer interface {
+	// Index replaces the corpus with the given documents.
+	Index(documents []string)
+}
+
+// Learner is implemented by scorers which adapt to reader feedback.
+type Learner interface {
+	// Learn replaces previously learned adjustments with ones derived from the examples.
+	Learn(ctx context.Context, examples []Example) error
+}
+
+const (
+	// maxWeightShift bounds interest weights to 1 ± maxWeightShift.
+	maxWeightShift = 0.5
+	// feedbackSmoothing is the number of ratings needed to move a weight halfway to its bound.
+	feedbackSmoothing = 5
+)
+
+// interestWeights derives a weight per interest from ratings of the stories it matched.
+// Interests without ratings keep the neutral weight of 1.
+func interestWeights(interests []string, examples []Example) map[string]float64 {
+	positive := make(map[string]int)
+	negative := make(map[string]int)
+
+	for _, example := range examples {
+		if example.Positive {
+			positive[example.Interest]++
+		} else {
+			negative[example.Interest]++
+		}
+	}
+
+	weights := make(map[string]float64, len(interests))
+
+	for _, interest := range interests {
+		balance := float64(positive[interest] - negative[interest])
+		total := float64(positive[interest] + negative[interest])
+
+		weights[interest] = 1 + maxWeightShift*balance/(total+feedbackSmoothing)
+	}
+
+	return weights
+}
+
+// interestWeight returns the learned weight of the interest, 1 when nothing was learned.
+func interestWeight(weights map[string]float64, interest string) float64 {
+	if weight, ok := weights[interest]; ok {
+		return weight
+	}
+
+	return 1
+}
@@ -0,0 +1,81 @@
+package scorer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Interest is a topic stories are scored against.
+type Interest struct {
+	Text     string
+	Weight   float64 // Multiplies the similarity to the interest, 0 means 1
+	MinScore float64 // Similarity below which the interest is not considered a match
+}
+
+// Interests creates interests of the topics with the default weight and no threshold.
+func Interests(texts ...string) []Interest {
+	interests := make([]Interest, len(texts))
+	for idx, text := range texts {
+		interests[idx] = Interest{Text: text, Weight: 0, MinScore: 0}
+	}
+
+	return interests
+}
+
+func (i Interest) weight() float64 {
+	if i.Weight == 0 {
+		return 1
+	}
+
+	return i.Weight
+}
+
+func interestTexts(interests []Interest) []string {
+	texts := make([]string, len(interests))
+	for idx, interest := range interests {
+		texts[idx] = interest.Text
+	}
+
+	return texts
+}
+
+// interestMatch is the interest a story resembles the most.
+type interestMatch struct {
+	Text       string
+	Similarity float64 // Weighted similarity, 0 when nothing matched
+}
+
+// bestMatch returns the interest with the highest weighted similarity among those reaching their minimum.
+// Learned weights, if any, further scale the similarity.
+func bestMatch(interests []Interest, similarity func(idx int) float64, learned map[string]float64) interestMatch {
+	best := interestMatch{Text: "", Similarity: 0}
+
+	for idx, interest := range interests {
+		sim := similarity(idx)
+		if sim < interest.MinScore {
+			continue
+		}
+
+		sim *= interest.weight() * interestWeight(learned, interest.Text)
+		if sim > best.Similarity {
+			best = interestMatch{Text: interest.Text, Similarity: sim}
+		}
+	}
+
+	return best
+}
+
+// explain describes the best interest and anti-interest matches of a story.
+func explain(positive, negative interestMatch) string {
+	var parts []string
+
+	if positive.Text != "" {
+		parts = append(parts, fmt.Sprintf("%s (%.2f)", positive.Text, positive.Similarity))
+	}
+
+	if negative.Text != "" {
+		parts = append(parts, fmt.Sprintf("not %s (-%.2f)", negative.Text, negative.Similarity))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -2,60 +2,117 @@ package scorer
 
 import (
 	"context"
+	"slices"
 	"strings"
+	"sync"
 )
 
 // KeywordScorer scores stories using simple keyword matching against interests.
 // This is a minimal fallback when embedding models are not desired.
 type KeywordScorer struct {
-	interests []string
-	keywords  map[string][]string // interest -> extracted keywords
+	interests     []Interest
+	antiInterests []Interest
+	keywords      map[string][]string // interest -> extracted keywords
+	fieldWeights  *FieldWeights
+	maxTokens     int
+
+	mux     *sync.RWMutex
+	weights map[string]float64 // interest -> weight learned from reader feedback
 }
 
 // NewKeywordScorer creates a keyword-based scorer.
 func NewKeywordScorer(cfg Config) (*KeywordScorer, error) {
 	keywordScorer := &KeywordScorer{
-		interests: cfg.Interests,
-		keywords:  make(map[string][]string),
+		interests:     cfg.Interests,
+		antiInterests: cfg.AntiInterests,
+		keywords:      make(map[string][]string),
+		fieldWeights:  cfg.FieldWeights,
+		maxTokens:     cfg.MaxTokens,
+		mux:           &sync.RWMutex{},
+		weights:       nil,
 	}
 
 	// Extract keywords from each interest phrase
-	for _, interest := range cfg.Interests {
-		keywordScorer.keywords[interest] = extractKeywords(interest)
+	for _, interest := range append(slices.Clone(cfg.Interests), cfg.AntiInterests...) {
+		keywordScorer.keywords[interest.Text] = extractKeywords(interest.Text)
 	}
 
 	return keywordScorer, nil
 }
 
-// Score computes a relevance score based on keyword matching.
-func (k *KeywordScorer) Score(_ context.Context, title string) (Score, error) {
-	titleLower := strings.ToLower(title)
+// Score computes a relevance score based on keyword matching averaged over weighted story fields,
+// lowered by keyword matches of anti-interests.
+func (k *KeywordScorer) Score(_ context.Context, story Story) (Score, error) {
+	fields := story.fields(k.fieldWeights, k.maxTokens)
+	for idx := range fields {
+		fields[idx].Text = strings.ToLower(fields[idx].Text)
+	}
 
-	maxScore := 0.0
-	bestMatch := ""
+	k.mux.RLock()
+	defer k.mux.RUnlock()
 
-	for interest, keywords := range k.keywords {
-		matchCount := 0
+	positive := bestMatch(k.interests, func(idx int) float64 {
+		return k.fieldsMatchRatio(fields, k.interests[idx])
+	}, k.weights)
+	negative := bestMatch(k.antiInterests, func(idx int) float64 {
+		return k.fieldsMatchRatio(fields, k.antiInterests[idx])
+	}, nil)
 
-		for _, kw := range keywords {
-			if strings.Contains(titleLower, kw) {
-				matchCount++
-			}
-		}
+	return Score{
+		Value:    min(max(positive.Similarity-negative.Similarity, 0), 1),
+		Reason:   explain(positive, negative),
+		Interest: positive.Text,
+	}, nil
+}
+
+// ScoreBatch scores the stories one by one, keyword matching gains nothing from batching.
+func (k *KeywordScorer) ScoreBatch(ctx context.Context, stories []Story) ([]Score, error) {
+	return scoreEach(ctx, k, stories)
+}
+
+// fieldsMatchRatio returns the weighted average of the interest match ratios of the lowercase fields.
+func (k *KeywordScorer) fieldsMatchRatio(fields []storyField, interest Interest) float64 {
+	var ratio, totalWeight float64
+
+	for _, field := range fields {
+		ratio += field.Weight * k.matchRatio(field.Text, interest)
+		totalWeight += field.Weight
+	}
+
+	if totalWeight == 0 {
+		return 0
+	}
+
+	return ratio / totalWeight
+}
 
-		if len(keywords) > 0 {
-			score := float64(matchCount) / float64(len(keywords))
-			if score > maxScore {
-				maxScore = score
-				bestMatch = interest
-			}
+// matchRatio returns the share of the interest keywords found in the lowercase text.
+func (k *KeywordScorer) matchRatio(textLower string, interest Interest) float64 {
+	keywords := k.keywords[interest.Text]
+	if len(keywords) == 0 {
+		return 0
+	}
+
+	matchCount := 0
+
+	for _, kw := range keywords {
+		if strings.Contains(textLower, kw) {
+			matchCount++
 		}
 	}
 
-	return Score{
-		Value:  maxScore,
-		Reason: bestMatch,
-	}, nil
+	return float64(matchCount) / float64(len(keywords))
+}
+
+// Learn reweights interests by ratings of the stories they matched.
+func (k *KeywordScorer) Learn(_ context.Context, examples []Example) error {
+	weights := interestWeights(interestTexts(k.interests), examples)
+
+	k.mux.Lock()
+	k.weights = weights
+	k.mux.Unlock()
+
+	return nil
 }
 
 // Name returns the scorer identifier.
@@ -70,26 +127,6 @@ func (k *KeywordScorer) Close() error {
 
 // extractKeywords extracts meaningful keywords from an interest phrase.
 func extractKeywords(phrase string) []string {
-	// Common stop words to filter out
-	stopWords := map[string]bool{
-		"a": true, "an": true, "the": true, "and": true, "or": true,
-		"of": true, "in": true, "to": true, "for": true, "with": true,
-		"on": true, "at": true, "by": true, "from": true, "is": true,
-		"are": true, "was": true, "were": true, "be": true, "been": true,
-		"being": true, "have": true, "has": true, "had": true, "do": true,
-		"does": true, "did": true, "will": true, "would": true, "could": true,
-		"should": true, "may": true, "might": true, "must": true, "shall": true,
-		"about": true, "into": true, "through": true, "during": true,
-		"before": true, "after": true, "above": true, "below": true,
-		"between": true, "under": true, "again": true, "further": true,
-		"then": true, "once": true, "here": true, "there": true, "when": true,
-		"where": true, "why": true, "how": true, "all": true, "each": true,
-		"few": true, "more": true, "most": true, "other": true, "some": true,
-		"such": true, "no": true, "nor": true, "not": true, "only": true,
-		"own": true, "same": true, "so": true, "than": true, "too": true,
-		"very": true, "just": true, "also": true, "now": true, "new": true,
-	}
-
 	words := strings.Fields(strings.ToLower(phrase))
 	keywords := make([]string, 0, len(words))
 
@@ -107,3 +144,25 @@ func extractKeywords(phrase string) []string {
 
 	return keywords
 }
+
+// stopWords are common words which carry no topic.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true,
+	"of": true, "in": true, "to": true, "for": true, "with": true,
+	"on": true, "at": true, "by": true, "from": true, "is": true,
+	"are": true, "was": true, "were": true, "be": true, "been": true,
+	"being": true, "have": true, "has": true, "had": true, "do": true,
+	"does": true, "did": true, "will": true, "would": true, "could": true,
+	"should": true, "may": true, "might": true, "must": true, "shall": true,
+	"about": true, "into": true, "through": true, "during": true,
+	"before": true, "after": true, "above": true, "below": true,
+	"between": true, "under": true, "again": true, "further": true,
+	"then": true, "once": true, "here": true, "there": true, "when": true,
+	"where": true, "why": true, "how": true, "all": true, "each": true,
+	"few": true, "more": true, "most": true, "other": true, "some": true,
+	"such": true, "no": true, "nor": true, "not": true, "only": true,
+	"own": true, "same": true, "so": true, "than": true, "too": true,
+	"very": true, "just": true, "also": true, "now": true, "new": true,
+}
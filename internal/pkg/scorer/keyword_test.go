@@ -0,0 +1,51 @@
+package scorer_test
+
+import (
+	"context"
+	"mynews/internal/pkg/scorer"
+	"testing"
+)
+
+func TestKeywordScorerAntiInterests(t *testing.T) {
+	t.Parallel()
+
+	keywordScorer, err := scorer.NewKeywordScorer(scorer.Config{
+		Provider: scorer.ProviderKeyword,
+		Interests: []scorer.Interest{
+			{Text: "golang release", Weight: 0, MinScore: 0},
+			{Text: "rust compiler", Weight: 0.5, MinScore: 0},
+		},
+		AntiInterests: []scorer.Interest{{Text: "crypto scam", Weight: 0, MinScore: 1}},
+		ModelDir:      "",
+		ModelName:     "",
+		FieldWeights:  nil,
+		MaxTokens:     0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		title    string
+		value    float64
+		interest string
+	}{
+		{title: "Golang release notes", value: 1, interest: "golang release"},
+		{title: "New rust compiler", value: 0.5, interest: "rust compiler"},
+		{title: "Golang crypto wallet", value: 0.5, interest: "golang release"},
+		{title: "Golang crypto scam", value: 0, interest: "golang release"},
+		{title: "Weather today", value: 0, interest: ""},
+	}
+
+	for _, test := range tests {
+		score, err := keywordScorer.Score(context.Background(), scorer.Story{Title: test.title, Summary: "", Content: "", Categories: nil, Source: ""})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if score.Value != test.value || score.Interest != test.interest {
+			t.Errorf("%q: expected %v (%q), got %v (%q, %s)",
+				test.title, test.value, test.interest, score.Value, score.Interest, score.Reason)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package scorer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLLMTimeout bounds a single completion request, local models on a CPU are slow.
+	DefaultLLMTimeout = time.Minute
+
+	// llmCacheSize bounds the number of remembered completions.
+	llmCacheSize = 5000
+)
+
+var (
+	errLLMConfigMissing = errors.New("llm provider requires an endpoint and a model")
+	errLLMBadStatus     = errors.New("unexpected response status")
+	errLLMNoChoices     = errors.New("completion has no choices")
+	errLLMNoJSON        = errors.New("completion holds no JSON object")
+)
+
+// LLMConfig configures scoring with a chat model behind an OpenAI-compatible API (e.g. llama.cpp or Ollama).
+type LLMConfig struct {
+	Endpoint string // Base URL of the API, e.g. "http://localhost:11434/v1"
+	Model    string
+	APIKey   string        // Sent as a bearer token when set
+	Timeout  time.Duration // Per request, 0 uses DefaultLLMTimeout
+	Fallback string        // Provider scoring stories when the model fails, empty to fail
+}
+
+// LLMScorer asks a chat model to rate how well the story matches the interests.
+type LLMScorer struct {
+	cfg           LLMConfig
+	interests     []Interest
+	antiInterests []Interest
+	fallback      Scorer
+	client        *http.Client
+	maxTokens     int // Summary and content are truncated like fields of the other providers
+
+	mux   *sync.Mutex
+	cache map[string]Score // prompt hash -> score, cleared when full
+}
+
+// NewLLMScorer creates a scorer using the chat model, falling back to the given scorer (may be nil) on failures.
+func NewLLMScorer(cfg Config, fallback Scorer) (*LLMScorer, error) {
+	if cfg.LLM == nil || cfg.LLM.Endpoint == "" || cfg.LLM.Model == "" {
+		return nil, errLLMConfigMissing
+	}
+
+	if len(cfg.Interests) == 0 {
+		return nil, errNoInterests
+	}
+
+	timeout := cfg.LLM.Timeout
+	if timeout == 0 {
+		timeout = DefaultLLMTimeout
+	}
+
+	return &LLMScorer{
+		cfg:           *cfg.LLM,
+		interests:     cfg.Interests,
+		antiInterests: cfg.AntiInterests,
+		fallback:      fallback,
+		client:        &http.Client{Timeout: timeout}, //nolint:exhaustruct // only the timeout matters
+		maxTokens:     cfg.MaxTokens,
+		mux:           &sync.Mutex{},
+		cache:         make(map[string]Score),
+	}, nil
+}
+
+// Score asks the model to rate the story, using the fallback scorer when the request fails.
+func (l *LLMScorer) Score(ctx context.Context, story Story) (Score, error) {
+	prompt := l.prompt(story)
+	key := hashText(prompt)
+
+	l.mux.Lock()
+	cached, ok := l.cache[key]
+	l.mux.Unlock()
+
+	if ok {
+		return cached, nil
+	}
+
+	score, err := l.complete(ctx, prompt)
+	if err != nil {
+		if l.fallback == nil {
+			return Score{}, err
+		}
+
+		// fallback scores are not cached, the model may be back on the next cycle
+		return l.fallback.Score(ctx, story)
+	}
+
+	l.mux.Lock()
+	if len(l.cache) >= llmCacheSize {
+		clear(l.cache)
+	}
+
+	l.cache[key] = score
+	l.mux.Unlock()
+
+	return score, nil
+}
+
+// ScoreBatch scores the stories one by one, as chat completions are requested per story.
+func (l *LLMScorer) ScoreBatch(ctx context.Context, stories []Story) ([]Score, error) {
+	return scoreEach(ctx, l, stories)
+}
+
+// Learn passes the feedback to the fallback scorer, the model itself does not learn.
+func (l *LLMScorer) Learn(ctx context.Context, examples []Example) error {
+	if learner, ok := l.fallback.(Learner); ok {
+		return learner.Learn(ctx, examples) //nolint:wrapcheck // passed through as is
+	}
+
+	return nil
+}
+
+// Index passes the corpus to the fallback scorer.
+func (l *LLMScorer) Index(documents []string) {
+	if indexer, ok := l.fallback.(Indexer); ok {
+		indexer.Index(documents)
+	}
+}
+
+// Name returns the scorer identifier.
+func (l *LLMScorer) Name() string {
+	return ProviderLLM
+}
+
+// Close releases the fallback scorer.
+func (l *LLMScorer) Close() error {
+	if l.fallback == nil {
+		return nil
+	}
+
+	return l.fallback.Close() //nolint:wrapcheck // passed through as is
+}
+
+const llmSystemPrompt = `You rate how relevant a news story is to a reader.
+Respond with a single JSON object and nothing else:
+{"score": <number from 0 to 1>, "reason": "<one sentence>", "interest": "<the matching interest, or empty>"}`
+
+func (l *LLMScorer) prompt(story Story) string {
+	var prompt strings.Builder
+
+	prompt.WriteString("Reader interests:\n")
+
+	for _, interest := range l.interests {
+		prompt.WriteString("- " + interest.Text + "\n")
+	}
+
+	if len(l.antiInterests) != 0 {
+		prompt.WriteString("The reader does not want stories about:\n")
+
+		for _, interest := range l.antiInterests {
+			prompt.WriteString("- " + interest.Text + "\n")
+		}
+	}
+
+	prompt.WriteString("\nStory:\nTitle: " + story.Title + "\n")
+
+	if story.Source != "" {
+		prompt.WriteString("Source: " + story.Source + "\n")
+	}
+
+	if len(story.Categories) != 0 {
+		prompt.WriteString("Categories: " + strings.Join(story.Categories, ", ") + "\n")
+	}
+
+	if summary := truncateWords(story.Summary, l.maxTokens); summary != "" {
+		prompt.WriteString("Summary: " + summary + "\n")
+	}
+
+	if content := truncateWords(story.Content, l.maxTokens); content != "" {
+		prompt.WriteString("Content: " + content + "\n")
+	}
+
+	return prompt.String()
+}
+
+type llmMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+//nolint:tagliatelle // required structure for OpenAI-compatible requests
+type llmRequest struct {
+	Model          string            `json:"model"`
+	Messages       []llmMessage      `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+type llmResponse struct {
+	Choices []struct {
+		Message llmMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (l *LLMScorer) complete(ctx context.Context, prompt string) (Score, error) {
+	requestBody, err := json.Marshal(llmRequest{
+		Model: l.cfg.Model,
+		Messages: []llmMessage{
+			{Role: "system", Content: llmSystemPrompt},
+			{Role: "user", Content: prompt},
+		},
+		Temperature:    0,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return Score{}, fmt.Errorf("preparing request body: %w", err)
+	}
+
+	requestURL := strings.TrimSuffix(l.cfg.Endpoint, "/") + "/chat/completions"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(requestBody))
+	if err != nil {
+		return Score{}, fmt.Errorf("building request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if l.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.cfg.APIKey)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return Score{}, fmt.Errorf("requesting completion: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Score{}, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return Score{}, fmt.Errorf("%w: %d", errLLMBadStatus, resp.StatusCode)
+	}
+
+	var completion llmResponse
+
+	err = json.Unmarshal(body, &completion)
+	if err != nil {
+		return Score{}, fmt.Errorf("decoding response body: %w", err)
+	}
+
+	if len(completion.Choices) == 0 {
+		return Score{}, errLLMNoChoices
+	}
+
+	return l.parseScore(completion.Choices[0].Message.Content)
+}
+
+// parseScore reads the JSON object of the completion, tolerating text or code fences around it.
+func (l *LLMScorer) parseScore(content string) (Score, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return Score{}, errLLMNoJSON
+	}
+
+	var rating struct {
+		Score    float64 `json:"score"`
+		Reason   string  `json:"reason"`
+		Interest string  `json:"interest"`
+	}
+
+	err := json.Unmarshal([]byte(content[start:end+1]), &rating)
+	if err != nil {
+		return Score{}, fmt.Errorf("decoding rating: %w", err)
+	}
+
+	// models may paraphrase the interest, only exact ones are usable for routing
+	if !slices.Contains(interestTexts(l.interests), rating.Interest) {
+		rating.Interest = ""
+	}
+
+	return Score{
+		Value:    min(max(rating.Score, 0), 1),
+		Reason:   strings.TrimSpace(rating.Reason),
+		Interest: rating.Interest,
+	}, nil
+}
+
+func hashText(text string) string {
+	hash := sha256.Sum256([]byte(text))
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,161 @@
+package scorer_test
+
+import (
+	"context"
+	"encoding/json"
+	"mynews/internal/pkg/scorer"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newLLMScorer(t *testing.T, endpoint string, fallback scorer.Scorer) *scorer.LLMScorer {
+	t.Helper()
+
+	llmScorer, err := scorer.NewLLMScorer(scorer.Config{
+		Provider:      scorer.ProviderLLM,
+		Interests:     scorer.Interests("AI", "kubernetes releases"),
+		AntiInterests: nil,
+		ModelDir:      "",
+		ModelName:     "",
+		FieldWeights:  nil,
+		MaxTokens:     0,
+		Cache:         nil,
+		Workers:       0,
+		LLM: &scorer.LLMConfig{
+			Endpoint: endpoint,
+			Model:    "test",
+			APIKey:   "",
+			Timeout:  0,
+			Fallback: "",
+		},
+		Composite: nil,
+	}, fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return llmScorer
+}
+
+func TestLLMScorer(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		content := "```json\n" +
+			`{"score": 1.3, "reason": "A new Kubernetes version.", "interest": "kubernetes releases"}` +
+			"\n```"
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	}))
+	defer server.Close()
+
+	llmScorer := newLLMScorer(t, server.URL+"/v1", nil)
+	story := scorer.Story{Title: "Kubernetes 1.40 released", Summary: "", Content: "", Categories: nil, Source: ""}
+
+	for range 2 {
+		score, err := llmScorer.Score(context.Background(), story)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if score.Value != 1 || score.Reason != "A new Kubernetes version." || score.Interest != "kubernetes releases" {
+			t.Fatalf("unexpected score %+v", score)
+		}
+	}
+
+	if requests.Load() != 1 {
+		t.Fatalf("expected a cached rating, got %d requests", requests.Load())
+	}
+}
+
+func TestLLMScorerFallback(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	story := scorer.Story{Title: "Kubernetes releases", Summary: "", Content: "", Categories: nil, Source: ""}
+
+	_, err := newLLMScorer(t, server.URL, nil).Score(context.Background(), story)
+	if err == nil {
+		t.Fatal("expected an error without a fallback")
+	}
+
+	keywordScorer, err := scorer.NewKeywordScorer(scorer.Config{
+		Provider:      scorer.ProviderKeyword,
+		Interests:     scorer.Interests("kubernetes releases"),
+		AntiInterests: nil,
+		ModelDir:      "",
+		ModelName:     "",
+		FieldWeights:  nil,
+		MaxTokens:     0,
+		Cache:         nil,
+		Workers:       0,
+		LLM:           nil,
+		Composite:     nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	score, err := newLLMScorer(t, server.URL, keywordScorer).Score(context.Background(), story)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if score.Value == 0 {
+		t.Fatalf("expected the fallback score, got %+v", score)
+	}
+}
+
+func TestLLMScorerTruncatesPrompt(t *testing.T) {
+	t.Parallel()
+
+	var prompt atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		prompt.Store(request.Messages[len(request.Messages)-1].Content)
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": `{"score": 0.5}`}}},
+		})
+	}))
+	defer server.Close()
+
+	content := strings.Repeat("word ", scorer.DefaultMaxTokens) + "overflow"
+	story := scorer.Story{Title: "Kubernetes releases", Summary: "", Content: content, Categories: nil, Source: ""}
+
+	_, err := newLLMScorer(t, server.URL, nil).Score(context.Background(), story)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent, _ := prompt.Load().(string)
+	if !strings.Contains(sent, "Content: word") || strings.Contains(sent, "overflow") {
+		t.Errorf("expected the content cut to %d words, got prompt:\n%s", scorer.DefaultMaxTokens, sent)
+	}
+}
@@ -0,0 +1,152 @@
+package scorer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/nlpodyssey/cybertron/pkg/tasks/textencoding"
+)
+
+// Pool creates scorers for several interest sets, loading each embedding model only once.
+// A model is dropped once the last scorer using it is closed.
+type Pool struct {
+	mux    *sync.Mutex
+	models map[string]*pooledModel // model dir and name -> loaded model
+}
+
+// pooledModel is a loaded model along with the number of open scorers using it.
+type pooledModel struct {
+	model textencoding.Interface
+	users int
+}
+
+// NewPool creates an empty scorer pool.
+func NewPool() *Pool {
+	return &Pool{
+		mux:    &sync.Mutex{},
+		models: make(map[string]*pooledModel),
+	}
+}
+
+// Scorer creates a scorer of the registered provider of the config, reusing a model loaded for a previous one.
+func (p *Pool) Scorer(cfg Config) (Scorer, error) {
+	providerFactory, err := factory(cfg.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return providerFactory(cfg, p)
+}
+
+func (p *Pool) embeddingScorer(cfg Config) (Scorer, error) {
+	if len(cfg.Interests) == 0 {
+		return nil, errNoInterests
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	modelKey := modelDirKey(cfg.ModelDir) + "\x00" + cfg.ModelName
+
+	pooled, ok := p.models[modelKey]
+	if !ok {
+		model, err := loadModel(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		pooled = &pooledModel{model: model, users: 0}
+		p.models[modelKey] = pooled
+	}
+
+	embeddingScorer, err := newEmbeddingScorer(cfg, pooled.model)
+	if err != nil {
+		if pooled.users == 0 {
+			delete(p.models, modelKey)
+		}
+
+		return nil, err
+	}
+
+	pooled.users++
+	embeddingScorer.release = func() { p.release(modelKey) }
+
+	return embeddingScorer, nil
+}
+
+// release drops the model once no open scorer uses it, freeing its memory.
+func (p *Pool) release(modelKey string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	pooled, ok := p.models[modelKey]
+	if !ok {
+		return
+	}
+
+	pooled.users--
+	if pooled.users <= 0 {
+		delete(p.models, modelKey)
+	}
+}
+
+// modelDirKey identifies the model dir, so different spellings of the same dir share the loaded model.
+func modelDirKey(modelDir string) string {
+	absolute, err := filepath.Abs(modelDir)
+	if err != nil {
+		return filepath.Clean(modelDir)
+	}
+
+	return absolute
+}
+
+// llmScorer creates an LLM scorer with its fallback scorer, when configured.
+func (p *Pool) llmScorer(cfg Config) (Scorer, error) {
+	var fallback Scorer
+
+	if cfg.LLM != nil && cfg.LLM.Fallback != "" && cfg.LLM.Fallback != ProviderLLM {
+		fallbackConfig := cfg
+		fallbackConfig.Provider = cfg.LLM.Fallback
+
+		var err error
+
+		fallback, err = p.Scorer(fallbackConfig)
+		if err != nil {
+			return nil, fmt.Errorf("creating fallback scorer: %w", err)
+		}
+	}
+
+	return unwrap(NewLLMScorer(cfg, fallback))
+}
+
+// compositeScorer creates the member scorers of a composite one.
+func (p *Pool) compositeScorer(cfg Config) (Scorer, error) {
+	if cfg.Composite == nil || len(cfg.Composite.Members) == 0 {
+		return nil, errCompositeNoMembers
+	}
+
+	members := make([]Scorer, 0, len(cfg.Composite.Members))
+
+	for _, member := range cfg.Composite.Members {
+		if member.Provider == ProviderComposite {
+			return nil, errCompositeNested
+		}
+
+		memberConfig := cfg
+		memberConfig.Provider = member.Provider
+
+		memberScorer, err := p.Scorer(memberConfig)
+		if err != nil {
+			for _, created := range members {
+				_ = created.Close()
+			}
+
+			return nil, fmt.Errorf("creating %s scorer: %w", member.Provider, err)
+		}
+
+		members = append(members, memberScorer)
+	}
+
+	return unwrap(NewCompositeScorer(*cfg.Composite, members))
+}
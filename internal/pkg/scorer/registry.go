@@ -0,0 +1,64 @@
+package scorer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var errUnknownProvider = errors.New("unknown scoring provider")
+
+// Factory creates a scorer of a provider, the pool lets it share models or build nested scorers.
+type Factory func(cfg Config, pool *Pool) (Scorer, error)
+
+//nolint:gochecknoglobals // providers register themselves once, like database/sql drivers
+var registry = struct {
+	mux       sync.RWMutex
+	factories map[string]Factory
+}{
+	mux:       sync.RWMutex{},
+	factories: make(map[string]Factory),
+}
+
+//nolint:gochecknoinits // built-in providers are registered the same way as external ones
+func init() {
+	Register(ProviderEmbedding, func(cfg Config, pool *Pool) (Scorer, error) { return pool.embeddingScorer(cfg) })
+	Register(ProviderKeyword, func(cfg Config, _ *Pool) (Scorer, error) { return unwrap(NewKeywordScorer(cfg)) })
+	Register(ProviderBM25, func(cfg Config, _ *Pool) (Scorer, error) { return unwrap(NewBM25Scorer(cfg)) })
+	Register(ProviderLLM, func(cfg Config, pool *Pool) (Scorer, error) { return pool.llmScorer(cfg) })
+	Register(ProviderComposite, func(cfg Config, pool *Pool) (Scorer, error) { return pool.compositeScorer(cfg) })
+}
+
+// Register makes a provider available by name, replacing an earlier one of the same name.
+func Register(provider string, factory Factory) {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+
+	registry.factories[provider] = factory
+}
+
+// factory returns the factory of the provider, the embedding one when no provider is set.
+func factory(provider string) (Factory, error) {
+	if provider == "" {
+		provider = ProviderEmbedding
+	}
+
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+
+	providerFactory, ok := registry.factories[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errUnknownProvider, provider)
+	}
+
+	return providerFactory, nil
+}
+
+// unwrap turns a typed scorer into the interface, keeping it nil on errors.
+func unwrap[T Scorer](scorer T, err error) (Scorer, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return scorer, nil
+}
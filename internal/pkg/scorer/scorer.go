@@ -7,19 +7,30 @@ const (
 	ProviderEmbedding = "embedding"
 	// ProviderKeyword uses simple keyword matching for scoring.
 	ProviderKeyword = "keyword"
+	// ProviderBM25 ranks stories by BM25 relevance of interest terms, weighted by their rarity in recent stories.
+	ProviderBM25 = "bm25"
+	// ProviderLLM asks a chat model behind an OpenAI-compatible API, see LLMScorer.
+	ProviderLLM = "llm"
+	// ProviderComposite combines other providers, see CompositeScorer.
+	ProviderComposite = "composite"
 )
 
 // Score represents the AI scoring result for a story.
 type Score struct {
-	Value  float64 // 0.0 to 1.0 (normalized relevance score)
-	Reason string  // Brief explanation of the score
+	Value    float64 // 0.0 to 1.0 (normalized relevance score)
+	Reason   string  // Brief explanation of the score
+	Interest string  // Best matching interest, empty when none matched
 }
 
 // Scorer evaluates story relevance based on user interests.
 type Scorer interface {
-	// Score evaluates a story title against configured interests.
+	// Score evaluates a story against configured interests.
 	// Returns a Score with Value between 0.0 and 1.0.
-	Score(ctx context.Context, title string) (Score, error)
+	Score(ctx context.Context, story Story) (Score, error)
+
+	// ScoreBatch evaluates the stories together, which is faster than one by one for model based scorers.
+	// Returns scores in the order of the stories.
+	ScoreBatch(ctx context.Context, stories []Story) ([]Score, error)
 
 	// Name returns the scorer identifier (e.g., "embedding", "keyword").
 	Name() string
@@ -28,13 +39,22 @@ type Scorer interface {
 	Close() error
 }
 
+// Embedder is implemented by scorers which can tell how similar texts are beyond shared words.
+type Embedder interface {
+	// Embed returns unit-length embeddings of the texts, their dot product is the cosine similarity.
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
 // Config holds scorer configuration.
 type Config struct {
-	// Provider specifies which scorer to use: "embedding" or "keyword"
+	// Provider specifies a registered scorer: "embedding" (default), "keyword", "bm25", "llm" or "composite"
 	Provider string
 
 	// Interests are the topics/themes to score stories against
-	Interests []string
+	Interests []Interest
+
+	// AntiInterests are topics lowering the score of stories resembling them
+	AntiInterests []Interest
 
 	// ModelDir is the directory to cache downloaded models
 	ModelDir string
@@ -42,18 +62,44 @@ type Config struct {
 	// ModelName is the HuggingFace model name for embedding scorer
 	// Defaults to "sentence-transformers/all-MiniLM-L6-v2"
 	ModelName string
+
+	// FieldWeights weighs story fields, nil uses DefaultFieldWeights
+	FieldWeights *FieldWeights
+
+	// MaxTokens truncates each story field, 0 uses DefaultMaxTokens
+	MaxTokens int
+
+	// Cache keeps embeddings across restarts, nil encodes every text
+	Cache *EmbeddingCache
+
+	// Workers bounds concurrent encoding of a batch, 0 uses the number of CPUs
+	Workers int
+
+	// LLM configures the llm provider
+	LLM *LLMConfig
+
+	// Composite configures the composite provider
+	Composite *CompositeConfig
 }
 
-// NewScorer creates a scorer based on configuration.
-func NewScorer(cfg Config) (*EmbeddingScorer, *KeywordScorer, error) {
-	switch cfg.Provider {
-	case ProviderKeyword:
-		keywordScorer, err := NewKeywordScorer(cfg)
+// scoreEach implements ScoreBatch for scorers which score stories independently.
+func scoreEach(ctx context.Context, scorer Scorer, stories []Story) ([]Score, error) {
+	scores := make([]Score, len(stories))
 
-		return nil, keywordScorer, err
-	default:
-		embeddingScorer, err := NewEmbeddingScorer(cfg)
+	for idx, story := range stories {
+		score, err := scorer.Score(ctx, story)
+		if err != nil {
+			return nil, err
+		}
 
-		return embeddingScorer, nil, err
+		scores[idx] = score
 	}
+
+	return scores, nil
+}
+
+// NewScorer creates a scorer of the registered provider of the config.
+// Use a Pool to share embedding models between several scorers.
+func NewScorer(cfg Config) (Scorer, error) {
+	return NewPool().Scorer(cfg)
 }
@@ -0,0 +1,212 @@
+package scorer
+
+import "strings"
+
+// stem reduces an English word to its stem with the Porter algorithm, e.g. "connections" to "connect".
+// Words with non ASCII letters are returned unchanged.
+func stem(word string) string {
+	if len(word) <= 2 || strings.IndexFunc(word, func(r rune) bool { return r < 'a' || r > 'z' }) != -1 {
+		return word
+	}
+
+	word = stemStep1a(word)
+	word = stemStep1b(word)
+	word = stemStep1c(word)
+	word = replaceSuffix(word, 0, stemStep2Suffixes)
+	word = replaceSuffix(word, 0, stemStep3Suffixes)
+	word = stemStep4(word)
+
+	return stemStep5(word)
+}
+
+//nolint:gochecknoglobals // read-only suffix tables, longer suffixes sharing an ending come first
+var (
+	stemStep2Suffixes = [][2]string{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+		{"abli", "able"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+		{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"},
+		{"fulness", "ful"}, {"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	}
+	stemStep3Suffixes = [][2]string{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	}
+	stemStep4Suffixes = []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment", "ent",
+		"ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+	}
+)
+
+func stemStep1a(word string) string {
+	switch {
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "ies"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+func stemStep1b(word string) string {
+	if strings.HasSuffix(word, "eed") {
+		if measure(word[:len(word)-3]) > 0 {
+			return word[:len(word)-1]
+		}
+
+		return word
+	}
+
+	var stemmed string
+
+	switch {
+	case strings.HasSuffix(word, "ed") && containsVowel(word[:len(word)-2]):
+		stemmed = word[:len(word)-2]
+	case strings.HasSuffix(word, "ing") && containsVowel(word[:len(word)-3]):
+		stemmed = word[:len(word)-3]
+	default:
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(stemmed, "at"), strings.HasSuffix(stemmed, "bl"), strings.HasSuffix(stemmed, "iz"):
+		return stemmed + "e"
+	case endsWithDoubleConsonant(stemmed) && !strings.ContainsAny(stemmed[len(stemmed)-1:], "lsz"):
+		return stemmed[:len(stemmed)-1]
+	case measure(stemmed) == 1 && endsWithCVC(stemmed):
+		return stemmed + "e"
+	default:
+		return stemmed
+	}
+}
+
+func stemStep1c(word string) string {
+	if strings.HasSuffix(word, "y") && containsVowel(word[:len(word)-1]) {
+		return word[:len(word)-1] + "i"
+	}
+
+	return word
+}
+
+func stemStep4(word string) string {
+	for _, suffix := range stemStep4Suffixes {
+		if !strings.HasSuffix(word, suffix) {
+			continue
+		}
+
+		stemmed := word[:len(word)-len(suffix)]
+
+		if suffix == "ion" && !strings.HasSuffix(stemmed, "s") && !strings.HasSuffix(stemmed, "t") {
+			return word
+		}
+
+		if measure(stemmed) > 1 {
+			return stemmed
+		}
+
+		return word
+	}
+
+	return word
+}
+
+func stemStep5(word string) string {
+	if strings.HasSuffix(word, "e") {
+		stemmed := word[:len(word)-1]
+		if m := measure(stemmed); m > 1 || (m == 1 && !endsWithCVC(stemmed)) {
+			word = stemmed
+		}
+	}
+
+	if strings.HasSuffix(word, "ll") && measure(word) > 1 {
+		word = word[:len(word)-1]
+	}
+
+	return word
+}
+
+// replaceSuffix replaces the longest matching suffix when the remaining stem has a measure above the minimum.
+func replaceSuffix(word string, minMeasure int, suffixes [][2]string) string {
+	longest := -1
+
+	for idx, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix[0]) && (longest == -1 || len(suffix[0]) > len(suffixes[longest][0])) {
+			longest = idx
+		}
+	}
+
+	if longest == -1 {
+		return word
+	}
+
+	stemmed := word[:len(word)-len(suffixes[longest][0])]
+	if measure(stemmed) > minMeasure {
+		return stemmed + suffixes[longest][1]
+	}
+
+	return word
+}
+
+func isConsonant(word string, idx int) bool {
+	switch word[idx] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return idx == 0 || !isConsonant(word, idx-1)
+	default:
+		return true
+	}
+}
+
+// measure counts vowel-consonant sequences of the word, m in [C](VC){m}[V].
+func measure(word string) int {
+	count := 0
+	idx := 0
+
+	for idx < len(word) && isConsonant(word, idx) {
+		idx++
+	}
+
+	for idx < len(word) {
+		for idx < len(word) && !isConsonant(word, idx) {
+			idx++
+		}
+
+		if idx == len(word) {
+			break
+		}
+
+		for idx < len(word) && isConsonant(word, idx) {
+			idx++
+		}
+
+		count++
+	}
+
+	return count
+}
+
+func containsVowel(word string) bool {
+	for idx := range len(word) {
+		if !isConsonant(word, idx) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func endsWithDoubleConsonant(word string) bool {
+	n := len(word)
+
+	return n >= 2 && word[n-1] == word[n-2] && isConsonant(word, n-1)
+}
+
+// endsWithCVC reports whether the word ends consonant-vowel-consonant, the last one not being w, x or y.
+func endsWithCVC(word string) bool {
+	n := len(word)
+
+	return n >= 3 && isConsonant(word, n-3) && !isConsonant(word, n-2) && isConsonant(word, n-1) &&
+		!strings.ContainsAny(word[n-1:], "wxy")
+}
@@ -0,0 +1,80 @@
+package scorer
+
+import (
+	"strings"
+)
+
+// DefaultMaxTokens approximates the input limit of sentence-transformers models, longer fields are truncated.
+const DefaultMaxTokens = 256
+
+// Story is the scored part of a feed item.
+type Story struct {
+	Title      string
+	Summary    string
+	Content    string // Excerpt of the full content
+	Categories []string
+	Source     string // Name of the source the story came from
+}
+
+// FieldWeights controls how much each story field contributes to the score, 0 ignores the field.
+type FieldWeights struct {
+	Title      float64
+	Summary    float64
+	Content    float64
+	Categories float64
+}
+
+// DefaultFieldWeights favors the title while letting the summary correct misleading headlines.
+//
+//nolint:gochecknoglobals // read-only defaults
+var DefaultFieldWeights = FieldWeights{
+	Title:      1,
+	Summary:    0.5,
+	Content:    0.25,
+	Categories: 0.25,
+}
+
+// storyField is a story field text with its weight.
+type storyField struct {
+	Text   string
+	Weight float64
+}
+
+// fields returns non-empty weighted fields of the story, each cut to maxTokens words.
+func (s Story) fields(weights *FieldWeights, maxTokens int) []storyField {
+	if weights == nil {
+		weights = &DefaultFieldWeights
+	}
+
+	candidates := []storyField{
+		{Text: s.Title, Weight: weights.Title},
+		{Text: s.Summary, Weight: weights.Summary},
+		{Text: s.Content, Weight: weights.Content},
+		{Text: strings.Join(s.Categories, ", "), Weight: weights.Categories},
+	}
+
+	fields := make([]storyField, 0, len(candidates))
+
+	for _, field := range candidates {
+		text := truncateWords(field.Text, maxTokens)
+		if text == "" || field.Weight <= 0 {
+			continue
+		}
+
+		fields = append(fields, storyField{Text: text, Weight: field.Weight})
+	}
+
+	return fields
+}
+
+// truncateWords cuts the text to maxTokens words, 0 uses DefaultMaxTokens.
+func truncateWords(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	// words are a cheap lower bound of model tokens, which split rare words further
+	words := strings.Fields(text)
+
+	return strings.Join(words[:min(len(words), maxTokens)], " ")
+}
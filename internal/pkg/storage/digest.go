@@ -0,0 +1,60 @@
+package storage
+
+import "time"
+
+// AddToDigest stores the story until the next digest of the app is sent.
+func (s *Storage) AddToDigest(app string, story QueuedStory) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.digests[app] == nil {
+		s.digests[app] = make(map[string]QueuedStory)
+	}
+
+	s.digests[app][story.ID] = story
+
+	return nil
+}
+
+// DigestStories returns stories accumulated for the next digest of the app, oldest first.
+func (s *Storage) DigestStories(app string) []QueuedStory {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	stories := make([]QueuedStory, 0, len(s.digests[app]))
+
+	for _, story := range s.digests[app] {
+		stories = append(stories, story)
+	}
+
+	sortQueued(stories)
+
+	return stories
+}
+
+// CompleteDigest removes the given stories from the digest of the app and records when it was sent.
+func (s *Storage) CompleteDigest(app string, stories []QueuedStory, sentAt time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, story := range stories {
+		delete(s.digests[app], story.ID)
+	}
+
+	s.lastDigest[app] = sentAt
+}
+
+// LastDigestAt returns when the last digest of the app was sent, zero if never.
+func (s *Storage) LastDigestAt(app string) time.Time {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return s.lastDigest[app]
+}
+
+// SetLastDigestAt records the time the digest schedule of the app is counted from.
+func (s *Storage) SetLastDigestAt(app string, at time.Time) {
+	s.mux.Lock()
+	s.lastDigest[app] = at
+	s.mux.Unlock()
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/parser"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Feedback is a reader's rating of a broadcast story.
+type Feedback struct {
+	StoryID  string    `json:"storyID"`
+	UserID   int64     `json:"userID"`
+	Positive bool      `json:"positive"`
+	Title    string    `json:"title"`
+	Reason   string    `json:"reason,omitempty"`   // Score explanation of the story when it was rated
+	Interest string    `json:"interest,omitempty"` // Best matching interest of the story when it was rated
+	At       time.Time `json:"at"`
+}
+
+// PutFeedback stores the rating, replacing an earlier rating of the same story by the same user.
+func (s *Storage) PutFeedback(app string, feedback Feedback) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.feedback[app] == nil {
+		s.feedback[app] = make(map[string]Feedback)
+	}
+
+	s.feedback[app][feedback.StoryID+":"+strconv.FormatInt(feedback.UserID, 10)] = feedback
+
+	return nil
+}
+
+// Feedback returns all ratings of the app stories, oldest first.
+func (s *Storage) Feedback(app string) []Feedback {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	feedback := make([]Feedback, 0, len(s.feedback[app]))
+
+	for _, rating := range s.feedback[app] {
+		feedback = append(feedback, rating)
+	}
+
+	sort.Slice(feedback, func(i, j int) bool {
+		return feedback[i].At.Before(feedback[j].At)
+	})
+
+	return feedback
+}
+
+// ForgetFeedbackBefore drops ratings of the app stories made before the given time.
+func (s *Storage) ForgetFeedbackBefore(app string, before time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for key, rating := range s.feedback[app] {
+		if rating.At.Before(before) {
+			delete(s.feedback[app], key)
+		}
+	}
+}
+
+// RememberStory keeps the story details for a while, so later events can refer to it by ID.
+// Only details needed to rate, dedupe and announce the story are kept, the feed item is dropped.
+func (s *Storage) RememberStory(app string, story QueuedStory) {
+	story.Story = broadcast.Story{
+		ID:       story.Story.ID,
+		Title:    story.Story.Title,
+		URL:      story.Story.URL,
+		Score:    story.Story.Score,
+		Scored:   story.Story.Scored,
+		Reason:   story.Story.Reason,
+		Interest: story.Story.Interest,
+		Source:   story.Story.Source,
+		Item:     parser.Item{},
+
+		AlsoCoveredBy: nil,
+		Trending:      false,
+
+		DeliveredTo: nil,
+	}
+	story.LastError = ""
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.history[app] == nil {
+		s.history[app] = make(map[string]QueuedStory)
+	}
+
+	s.history[app][story.ID] = story
+}
+
+// RecentStory returns a remembered story of the app.
+func (s *Storage) RecentStory(app, id string) (QueuedStory, bool) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	story, ok := s.history[app][id]
+
+	return story, ok
+}
+
+// RecentStories returns all remembered stories of the app, oldest first.
+func (s *Storage) RecentStories(app string) []QueuedStory {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	stories := make([]QueuedStory, 0, len(s.history[app]))
+	for _, story := range s.history[app] {
+		stories = append(stories, story)
+	}
+
+	sortQueued(stories)
+
+	return stories
+}
+
+// ForgetStoriesBefore drops remembered stories of the app enqueued before the given time.
+func (s *Storage) ForgetStoriesBefore(app string, before time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for id, story := range s.history[app] {
+		if story.EnqueuedAt.Before(before) {
+			delete(s.history[app], id)
+		}
+	}
+}
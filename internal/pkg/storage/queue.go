@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"mynews/internal/pkg/broadcast"
+	"sort"
+	"time"
+)
+
+// QueuedStory is a story waiting to be delivered by a broadcaster.
+type QueuedStory struct {
+	ID            string          `json:"id"`
+	Story         broadcast.Story `json:"story"`
+	Attempts      int             `json:"attempts"`
+	EnqueuedAt    time.Time       `json:"enqueuedAt"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     string          `json:"lastError,omitempty"`
+	Cluster       string          `json:"cluster,omitempty"`   // ID of the story kept out of its near-duplicates
+	TrendingAt    time.Time       `json:"trendingAt,omitzero"` // When the cluster kept by the story was announced trending
+}
+
+// Enqueue marks the story as pending delivery for the app.
+func (s *Storage) Enqueue(app string, story QueuedStory) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.pending[app] == nil {
+		s.pending[app] = make(map[string]QueuedStory)
+	}
+
+	s.pending[app][story.ID] = story
+
+	return nil
+}
+
+// DuePending returns pending stories of the app which are ready to be sent, oldest first.
+func (s *Storage) DuePending(app string, now time.Time) []QueuedStory {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	due := make([]QueuedStory, 0, len(s.pending[app]))
+
+	for _, story := range s.pending[app] {
+		if !story.NextAttemptAt.After(now) {
+			due = append(due, story)
+		}
+	}
+
+	sortQueued(due)
+
+	return due
+}
+
+// Dequeue removes the story from the pending queue of the app.
+func (s *Storage) Dequeue(app, id string) {
+	s.mux.Lock()
+	delete(s.pending[app], id)
+	s.mux.Unlock()
+}
+
+// MoveToDeadLetters removes the story from the pending queue and stores it as a dead letter.
+func (s *Storage) MoveToDeadLetters(app string, story QueuedStory) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.pending[app], story.ID)
+
+	if s.deadLetters[app] == nil {
+		s.deadLetters[app] = make(map[string]QueuedStory)
+	}
+
+	s.deadLetters[app][story.ID] = story
+}
+
+// DeadLetters returns dead letters of the app, oldest first.
+func (s *Storage) DeadLetters(app string) []QueuedStory {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	letters := make([]QueuedStory, 0, len(s.deadLetters[app]))
+
+	for _, story := range s.deadLetters[app] {
+		letters = append(letters, story)
+	}
+
+	sortQueued(letters)
+
+	return letters
+}
+
+// DeadLetterApps returns names of the apps which have dead letters.
+func (s *Storage) DeadLetterApps() []string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	apps := make([]string, 0, len(s.deadLetters))
+
+	for app, letters := range s.deadLetters {
+		if len(letters) != 0 {
+			apps = append(apps, app)
+		}
+	}
+
+	sort.Strings(apps)
+
+	return apps
+}
+
+// RemoveDeadLetter deletes the dead letter of the app.
+func (s *Storage) RemoveDeadLetter(app, id string) {
+	s.mux.Lock()
+	delete(s.deadLetters[app], id)
+	s.mux.Unlock()
+}
+
+func sortQueued(stories []QueuedStory) {
+	sort.Slice(stories, func(i, j int) bool {
+		if stories[i].EnqueuedAt.Equal(stories[j].EnqueuedAt) {
+			return stories[i].ID < stories[j].ID
+		}
+
+		return stories[i].EnqueuedAt.Before(stories[j].EnqueuedAt)
+	})
+}
+
+// UpdateQueued changes the story while it waits in the pending queue of one of the targets or in the app digest,
+// reporting whether it was found.
+func (s *Storage) UpdateQueued(app string, targets []string, id string, update func(story *QueuedStory)) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, queue := range s.queuesOf(app, targets) {
+		if story, ok := queue[id]; ok {
+			update(&story)
+			queue[id] = story
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// RemoveQueued takes the story out of the pending queue of one of the targets or out of the app digest.
+func (s *Storage) RemoveQueued(app string, targets []string, id string) (QueuedStory, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, queue := range s.queuesOf(app, targets) {
+		if story, ok := queue[id]; ok {
+			delete(queue, id)
+
+			return story, true
+		}
+	}
+
+	return QueuedStory{}, false
+}
+
+// queuesOf returns the pending queues of the targets followed by the digest of the app, s.mux must be held.
+func (s *Storage) queuesOf(app string, targets []string) []map[string]QueuedStory {
+	queues := make([]map[string]QueuedStory, 0, len(targets)+1)
+
+	for _, target := range targets {
+		queues = append(queues, s.pending[target])
+	}
+
+	return append(queues, s.digests[app])
+}
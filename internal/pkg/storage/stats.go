@@ -0,0 +1,22 @@
+package storage
+
+// Stats summarizes what the storage holds for an app.
+type Stats struct {
+	KnownStories  int
+	Pending       int
+	DeadLetters   int
+	DigestStories int
+}
+
+// Stats returns counts of stories stored for the app.
+func (s *Storage) Stats(app string) Stats {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return Stats{
+		KnownStories:  len(s.store[app]),
+		Pending:       len(s.pending[app]),
+		DeadLetters:   len(s.deadLetters[app]),
+		DigestStories: len(s.digests[app]),
+	}
+}
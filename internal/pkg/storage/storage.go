@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"mynews/internal/pkg/logger"
 	"os"
 	"sync"
@@ -11,19 +13,72 @@ import (
 )
 
 type Storage struct {
-	store map[string]map[string]time.Time
-	mux   *sync.RWMutex
+	store       map[string]map[string]time.Time
+	pending     map[string]map[string]QueuedStory
+	deadLetters map[string]map[string]QueuedStory
+	digests     map[string]map[string]QueuedStory
+	lastDigest  map[string]time.Time
+	history     map[string]map[string]QueuedStory
+	feedback    map[string]map[string]Feedback
+	mux         *sync.RWMutex
+}
+
+// fileVersion is the current version of the storage file layout.
+// Files without a version are the legacy layout holding only sent story keys.
+const fileVersion = 2
+
+type fileContents struct {
+	Version     int                               `json:"version"`
+	Keys        map[string]map[string]time.Time   `json:"keys"`
+	Pending     map[string]map[string]QueuedStory `json:"pending"`
+	DeadLetters map[string]map[string]QueuedStory `json:"deadLetters"`
+	Digests     map[string]map[string]QueuedStory `json:"digests"`
+	LastDigest  map[string]time.Time              `json:"lastDigest"`
+	History     map[string]map[string]QueuedStory `json:"history"`
+	Feedback    map[string]map[string]Feedback    `json:"feedback"`
 }
 
 func New() Storage {
 	var s Storage
 
 	s.store = make(map[string]map[string]time.Time)
+	s.pending = make(map[string]map[string]QueuedStory)
+	s.deadLetters = make(map[string]map[string]QueuedStory)
+	s.digests = make(map[string]map[string]QueuedStory)
+	s.lastDigest = make(map[string]time.Time)
+	s.history = make(map[string]map[string]QueuedStory)
+	s.feedback = make(map[string]map[string]Feedback)
 	s.mux = &sync.RWMutex{}
 
 	return s
 }
 
+// Clone returns a deep copy of the storage, changes to either one leave the other as is.
+func (s *Storage) Clone() Storage {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return Storage{
+		store:       cloneApps(s.store),
+		pending:     cloneApps(s.pending),
+		deadLetters: cloneApps(s.deadLetters),
+		digests:     cloneApps(s.digests),
+		lastDigest:  maps.Clone(s.lastDigest),
+		history:     cloneApps(s.history),
+		feedback:    cloneApps(s.feedback),
+		mux:         &sync.RWMutex{},
+	}
+}
+
+func cloneApps[V any](apps map[string]map[string]V) map[string]map[string]V {
+	cloned := make(map[string]map[string]V, len(apps))
+	for app, values := range apps {
+		cloned[app] = maps.Clone(values)
+	}
+
+	return cloned
+}
+
 func (s *Storage) PutKey(app, key string) error {
 	s.mux.Lock()
 
@@ -55,6 +110,7 @@ func (s *Storage) KeyExists(app, key string) (bool, error) {
 	return false, nil
 }
 
+// CleanupBefore drops sent story keys of the app which were not seen since the given time, keys of other apps stay.
 func (s *Storage) CleanupBefore(app string, before time.Time) {
 	s.mux.Lock()
 
@@ -64,7 +120,7 @@ func (s *Storage) CleanupBefore(app string, before time.Time) {
 
 	for key, lastSeenAt := range s.store[app] {
 		if lastSeenAt.Before(before) {
-			delete(s.store, key)
+			delete(s.store[app], key)
 		}
 	}
 
@@ -87,7 +143,19 @@ func (s *Storage) DumpToFile(filePath string) error {
 
 	defer func() { _ = dataFile.Close() }()
 
-	err = json.NewEncoder(dataFile).Encode(s.store)
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	err = json.NewEncoder(dataFile).Encode(fileContents{
+		Version:     fileVersion,
+		Keys:        s.store,
+		Pending:     s.pending,
+		DeadLetters: s.deadLetters,
+		Digests:     s.digests,
+		LastDigest:  s.lastDigest,
+		History:     s.history,
+		Feedback:    s.feedback,
+	})
 	if err != nil {
 		return fmt.Errorf("writing to data file: %w", err)
 	}
@@ -110,9 +178,23 @@ func (s *Storage) RecoverFromFile(filePath string, log *logger.Log, legacyAppNam
 
 	defer func() { _ = dataFile.Close() }()
 
+	data, err := io.ReadAll(dataFile)
+	if err != nil {
+		return fmt.Errorf("reading data file: %w", err)
+	}
+
+	var versioned fileContents
+
+	// legacy files fail to decode here, which is expected
+	if json.Unmarshal(data, &versioned) == nil && versioned.Version >= fileVersion {
+		s.restore(versioned)
+
+		return nil
+	}
+
 	var dataFileContents map[string]any
 
-	err = json.NewDecoder(dataFile).Decode(&dataFileContents)
+	err = json.Unmarshal(data, &dataFileContents)
 	if err != nil {
 		return fmt.Errorf("decoding config file: %w", err)
 	}
@@ -120,6 +202,53 @@ func (s *Storage) RecoverFromFile(filePath string, log *logger.Log, legacyAppNam
 	return s.parseFileContents(dataFileContents, legacyAppName)
 }
 
+func (s *Storage) restore(contents fileContents) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for app, keys := range contents.Keys {
+		if s.store[app] == nil {
+			s.store[app] = make(map[string]time.Time)
+		}
+
+		for key, lastSeenAt := range keys {
+			s.store[app][key] = lastSeenAt
+		}
+	}
+
+	restoreQueued(s.pending, contents.Pending)
+
+	restoreQueued(s.deadLetters, contents.DeadLetters)
+	restoreQueued(s.digests, contents.Digests)
+	restoreQueued(s.history, contents.History)
+
+	for app, feedback := range contents.Feedback {
+		if s.feedback[app] == nil {
+			s.feedback[app] = make(map[string]Feedback)
+		}
+
+		for key, rating := range feedback {
+			s.feedback[app][key] = rating
+		}
+	}
+
+	for app, sentAt := range contents.LastDigest {
+		s.lastDigest[app] = sentAt
+	}
+}
+
+func restoreQueued(target, source map[string]map[string]QueuedStory) {
+	for app, stories := range source {
+		if target[app] == nil {
+			target[app] = make(map[string]QueuedStory)
+		}
+
+		for id, story := range stories {
+			target[app][id] = story
+		}
+	}
+}
+
 var (
 	ErrBadInputValue = errors.New("bad input value")
 	ErrBadTimeValue  = errors.New("bad time value")
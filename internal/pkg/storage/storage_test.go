@@ -3,7 +3,11 @@ package storage_test
 
 import (
 	"math/rand"
+	"mynews/internal/pkg/broadcast"
+	"mynews/internal/pkg/logger"
 	"mynews/internal/pkg/storage"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -110,3 +114,196 @@ func TestStorageCleanup(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageCleanupRemovesStaleKeys(t *testing.T) {
+	t.Parallel()
+
+	store := storage.New()
+
+	err := store.PutKey("app", "stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.CleanupBefore("app", time.Now().Add(time.Second))
+
+	exists, err := store.KeyExists("app", "stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exists {
+		t.Error("key not seen since the cleanup time should be removed")
+	}
+}
+
+func TestStorageCleanupKeepsKeysOfOtherApps(t *testing.T) {
+	t.Parallel()
+
+	store := storage.New()
+
+	// the key doubles as an app name, cleaning up must not drop that app
+	for _, app := range []string{"app", "other"} {
+		err := store.PutKey(app, "other")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store.CleanupBefore("app", time.Now().Add(time.Second))
+
+	exists, err := store.KeyExists("other", "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !exists {
+		t.Error("keys of other apps should be kept")
+	}
+}
+
+func TestStorageQueueSurvivesDump(t *testing.T) {
+	t.Parallel()
+
+	store := storage.New()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	queued := storage.QueuedStory{
+		ID:            "pending",
+		Story:         broadcast.Story{Title: "title", URL: "https://example.com", Score: 0, Reason: ""},
+		Attempts:      1,
+		EnqueuedAt:    now,
+		NextAttemptAt: now.Add(time.Hour),
+		LastError:     "boom",
+		Cluster:       "",
+		TrendingAt:    time.Time{},
+	}
+
+	err := store.Enqueue("app", queued)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dead := queued
+	dead.ID = "dead"
+
+	store.MoveToDeadLetters("app", dead)
+
+	if due := store.DuePending("app", now); len(due) != 0 {
+		t.Errorf("expected no due stories, got %d", len(due))
+	}
+
+	filePath := filepath.Join(t.TempDir(), "data.json")
+
+	err = store.DumpToFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := storage.New()
+
+	err = recovered.RecoverFromFile(filePath, logger.New(logger.Error), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := recovered.DuePending("app", now.Add(time.Hour))
+	if len(due) != 1 || due[0].ID != "pending" || due[0].Attempts != 1 {
+		t.Errorf("unexpected pending stories after recovery: %+v", due)
+	}
+
+	letters := recovered.DeadLetters("app")
+	if len(letters) != 1 || letters[0].ID != "dead" || letters[0].LastError != "boom" {
+		t.Errorf("unexpected dead letters after recovery: %+v", letters)
+	}
+}
+
+func TestStorageRecoversLegacyFile(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "data.json")
+
+	err := os.WriteFile(filePath, []byte(`{"legacykey":"2020-04-20T00:00:00Z","app":{"key":"2020-04-20T00:00:00Z"}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := storage.New()
+
+	err = store.RecoverFromFile(filePath, logger.New(logger.Error), "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for app, key := range map[string]string{"legacy": "legacykey", "app": "key"} {
+		exists, err := store.KeyExists(app, key)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !exists {
+			t.Errorf("key '%s' of app '%s' should exist", key, app)
+		}
+	}
+}
+
+func TestStorageForgetsOldFeedback(t *testing.T) {
+	t.Parallel()
+
+	store := storage.New()
+	now := time.Now()
+
+	for idx, at := range []time.Time{now.Add(-time.Hour), now} {
+		err := store.PutFeedback("app", storage.Feedback{
+			StoryID:  strconv.Itoa(idx),
+			UserID:   1,
+			Positive: true,
+			Title:    "story",
+			Reason:   "",
+			Interest: "",
+			At:       at,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store.ForgetFeedbackBefore("app", now.Add(-time.Minute))
+
+	feedback := store.Feedback("app")
+	if len(feedback) != 1 || feedback[0].StoryID != "1" {
+		t.Errorf("expected only the recent rating to be kept, got %+v", feedback)
+	}
+}
+
+func TestStorageRemembersStoryDetailsOnly(t *testing.T) {
+	t.Parallel()
+
+	store := storage.New()
+
+	story := broadcast.Story{ID: "id", Title: "Title", URL: "https://example.com/story", Source: "Example"}
+	story.Item.Content = "full content of the story"
+
+	now := time.Now()
+
+	store.RememberStory("app", storage.QueuedStory{
+		ID:            "id",
+		Story:         story,
+		Attempts:      0,
+		EnqueuedAt:    now,
+		NextAttemptAt: now,
+		LastError:     "",
+		Cluster:       "",
+		TrendingAt:    time.Time{},
+	})
+
+	remembered, ok := store.RecentStory("app", "id")
+	if !ok {
+		t.Fatal("story should be remembered")
+	}
+
+	if remembered.Story.Item.Content != "" || remembered.Story.Title != story.Title || remembered.Story.URL != story.URL {
+		t.Errorf("expected details of the story without its feed item, got %+v", remembered.Story)
+	}
+}